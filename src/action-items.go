@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+const actionDueDateFormat = "2006-01-02"
+
+const defaultActionLinkType = "Relates"
+
+const defaultActionDigestInterval = 24 * time.Hour
+
+// Escaped user mentions look like <@U0123ABCD|name> or <@U0123ABCD>
+var slackUserMention = regexp.MustCompile(`^<@([A-Z0-9]+)(\|[^>]*)?>$`)
+
+// ActionItem is a follow-up of an incident tracked as a JIRA issue
+type ActionItem struct {
+	Key       string    `json:"key"`
+	Summary   string    `json:"summary"`
+	OwnerID   string    `json:"owner_id,omitempty"`
+	Due       string    `json:"due,omitempty"`
+	Done      bool      `json:"done,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ActionArguments are the options of the `action` command
+type ActionArguments struct {
+	Owner string
+	Due   string
+}
+
+// ******************************************************************************
+// Name				: parseActionArguments
+// Description: Function to parse the owner=@user and due=YYYY-MM-DD options of
+// 							the `action` command. The options can be quoted one by one
+// 							or together
+// ******************************************************************************
+func parseActionArguments(arguments []string) (ActionArguments, error) {
+	var options ActionArguments
+	for _, argument := range arguments {
+		for _, option := range strings.Fields(argument) {
+			switch {
+			case strings.HasPrefix(option, "owner="):
+				options.Owner = strings.TrimPrefix(option, "owner=")
+			case strings.HasPrefix(option, "due="):
+				options.Due = strings.TrimPrefix(option, "due=")
+				if _, err := time.Parse(actionDueDateFormat, options.Due); err != nil {
+					return options, errors.New("InvalidDueDate")
+				}
+			default:
+				return options, errors.New("InvalidOption")
+			}
+		}
+	}
+	if options.Owner == "@" {
+		return options, errors.New("InvalidOwner")
+	}
+	return options, nil
+}
+
+// ******************************************************************************
+// Name				: getActionOwnerID
+// Description: Function to get the slack user of the owner option, given as an
+// 							escaped mention or as @username
+// ******************************************************************************
+func getActionOwnerID(owner string) (string, error) {
+	if match := slackUserMention.FindStringSubmatch(owner); match != nil {
+		return match[1], nil
+	}
+	return getSlackUserIDByName(strings.TrimPrefix(owner, "@"))
+}
+
+// ******************************************************************************
+// Name				: isActionOverdue
+// Description: Function to check if an action item is past its due date
+// ******************************************************************************
+func isActionOverdue(item ActionItem, now time.Time) bool {
+	if item.Done || item.Due == "" {
+		return false
+	}
+	due, err := time.Parse(actionDueDateFormat, item.Due)
+	if err != nil {
+		return false
+	}
+	// Items are due until the end of their due date
+	return now.UTC().After(due.Add(24 * time.Hour))
+}
+
+// ******************************************************************************
+// Name				: formatActionItem
+// Description: Function to describe an action item in a message
+// ******************************************************************************
+func formatActionItem(item ActionItem, status string) string {
+	line := "<" + constants.JIRA.Endpoint + "/browse/" + item.Key + "|" + item.Key + "> " + item.Summary
+	var details []string
+	if status != "" {
+		details = append(details, status)
+	}
+	if item.OwnerID != "" {
+		details = append(details, "owner <@"+item.OwnerID+">")
+	}
+	if item.Due != "" {
+		details = append(details, "due "+item.Due)
+	}
+	if len(details) > 0 {
+		line += " (" + strings.Join(details, ", ") + ")"
+	}
+	return line
+}
+
+// ******************************************************************************
+// Name				: refreshActionItems
+// Description: Function to load the JIRA status of the open action items of an
+// 							incident and remember the ones that are done
+// ******************************************************************************
+func refreshActionItems(record IncidentRecord) (IncidentRecord, map[string]string) {
+	statuses := map[string]string{}
+	done := map[string]bool{}
+	for _, item := range record.ActionItems {
+		status, isDone, err := getJiraIssueStatus(item.Key)
+		if err != nil {
+			continue
+		}
+		statuses[item.Key] = status
+		if isDone && !item.Done {
+			done[item.Key] = true
+		}
+	}
+	if len(done) == 0 {
+		return record, statuses
+	}
+	updated, err := incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		for i := range record.ActionItems {
+			if done[record.ActionItems[i].Key] {
+				record.ActionItems[i].Done = true
+			}
+		}
+	})
+	if err != nil {
+		log.Error("refreshActionItems Error: ", err)
+		return record, statuses
+	}
+	return updated, statuses
+}
+
+// ******************************************************************************
+// Name				: actionCommandService
+// Description: Function to create a JIRA issue for a follow-up of the incident
+// 							and link it to the JIRA issue of the incident
+// ******************************************************************************
+func actionCommandService(s slack.SlashCommand, arguments []string) {
+	record, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	if record.JiraKey == "" {
+		response := SlashResponse{"ephemeral", "ERROR!! The incident has no JIRA issue to link the action item to."}
+		slackCommandResponse(response, s)
+		return
+	}
+	options, _ := parseActionArguments(arguments[2:])
+	item := ActionItem{
+		Summary:   arguments[1],
+		Due:       options.Due,
+		CreatedBy: s.UserID,
+		CreatedAt: time.Now(),
+	}
+	if options.Owner != "" {
+		item.OwnerID, err = getActionOwnerID(options.Owner)
+		if err != nil {
+			msg := "ERROR!! No Slack user found for " + options.Owner + "\n" + constants.ValidationMessages.TryAgain
+			response := SlashResponse{"ephemeral", msg}
+			slackCommandResponse(response, s)
+			return
+		}
+	}
+
+	issue, err := createJiraActionItem(record, item)
+	if err != nil {
+		msg := "ERROR!! Error creating JIRA Issue: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	item.Key = issue.Key
+	linkType := constants.JIRA.Actions.LinkType
+	if linkType == "" {
+		linkType = defaultActionLinkType
+	}
+	linkErr := linkJiraIssues(record.JiraKey, item.Key, linkType)
+
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.ActionItems = append(record.ActionItems, item)
+	})
+	if err != nil {
+		log.Error("actionCommandService Error: ", err)
+	}
+	responseText := "Action item created: " + formatActionItem(item, "")
+	if linkErr != nil {
+		responseText += "\nThe action item could not be linked to " + record.JiraKey + ": " + linkErr.Error()
+	}
+	response := SlashResponse{"in_channel", responseText}
+	slackCommandResponse(response, s)
+}
+
+// ******************************************************************************
+// Name				: actionsCommandService
+// Description: Function to list the action items of the incident with their
+// 							JIRA status
+// ******************************************************************************
+func actionsCommandService(s slack.SlashCommand, arguments []string) {
+	record, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	if len(record.ActionItems) == 0 {
+		response := SlashResponse{"ephemeral", "The incident has no action items. Use /falcon \"action\" \"<summary>\" \"owner=@user due=YYYY-MM-DD\" to add one."}
+		slackCommandResponse(response, s)
+		return
+	}
+	record, statuses := refreshActionItems(record)
+	lines := []string{"Action items of the incident:"}
+	now := time.Now()
+	for _, item := range record.ActionItems {
+		status := statuses[item.Key]
+		if isActionOverdue(item, now) {
+			status = "overdue"
+		}
+		lines = append(lines, "• "+formatActionItem(item, status))
+	}
+	response := SlashResponse{"ephemeral", strings.Join(lines, "\n")}
+	slackCommandResponse(response, s)
+}
+
+// ******************************************************************************
+// Name				: getActionDigestInterval
+// Description: Function to get how often the overdue action items are reported
+// ******************************************************************************
+func getActionDigestInterval() time.Duration {
+	if constants.JIRA.Actions.DigestInterval == "" {
+		return defaultActionDigestInterval
+	}
+	interval, err := time.ParseDuration(constants.JIRA.Actions.DigestInterval)
+	if err != nil || interval <= 0 {
+		log.Error("getActionDigestInterval Invalid Interval: ", constants.JIRA.Actions.DigestInterval)
+		return defaultActionDigestInterval
+	}
+	return interval
+}
+
+// ******************************************************************************
+// Name				: getActionDigestChannels
+// Description: Function to get the channels the overdue action items are
+// 							reported in, the notification channels unless configured
+// ******************************************************************************
+func getActionDigestChannels() []string {
+	if len(constants.JIRA.Actions.DigestChannelIDs) > 0 {
+		return constants.JIRA.Actions.DigestChannelIDs
+	}
+	return getNotificationChannels("")
+}
+
+// ******************************************************************************
+// Name				: digestOverdueActions
+// Description: Scheduled job to report the action items that are past their due
+// 							date and not done in JIRA yet
+// ******************************************************************************
+func digestOverdueActions(now time.Time) {
+	if now.Sub(incidentStore.GetLastActionDigestAt()) < getActionDigestInterval() {
+		return
+	}
+	records := incidentStore.List(func(record *IncidentRecord) bool {
+		for _, item := range record.ActionItems {
+			if isActionOverdue(item, now) {
+				return true
+			}
+		}
+		return false
+	})
+
+	var lines []string
+	for _, record := range records {
+		record, _ = refreshActionItems(record)
+		var overdue []string
+		for _, item := range record.ActionItems {
+			if isActionOverdue(item, now) {
+				overdue = append(overdue, "• "+formatActionItem(item, ""))
+			}
+		}
+		if len(overdue) > 0 {
+			lines = append(lines, "*"+record.Title+"* ("+record.JiraKey+")")
+			lines = append(lines, overdue...)
+		}
+	}
+	if len(lines) > 0 {
+		text := "Overdue incident action items:\n" + strings.Join(lines, "\n")
+		for _, channelID := range getActionDigestChannels() {
+			postMessageToChannel(channelID, text)
+		}
+	}
+	err := incidentStore.SetLastActionDigestAt(now)
+	if err != nil {
+		log.Error("digestOverdueActions Error: ", err)
+	}
+}
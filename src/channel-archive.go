@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// Archiving a channel is given up after this many failed attempts
+const maxArchiveAttempts = 5
+
+// Delay before archiving is retried after the first failed attempt. It doubles
+// with every further failure
+const archiveRetryDelay = 15 * time.Minute
+
+// ******************************************************************************
+// Name				: getArchiveDelay
+// Description: Function to get how long after resolution incident channels are
+// 							archived. Archiving is disabled when it is not configured
+// ******************************************************************************
+func getArchiveDelay() (time.Duration, error) {
+	if constants.Slack.Channels.ArchiveAfter == "" {
+		return 0, errors.New("channel archiving is not enabled")
+	}
+	delay, err := time.ParseDuration(constants.Slack.Channels.ArchiveAfter)
+	if err != nil || delay <= 0 {
+		return 0, errors.New("invalid slack.channels.archive_after \"" + constants.Slack.Channels.ArchiveAfter + "\"")
+	}
+	return delay, nil
+}
+
+// ******************************************************************************
+// Name				: getArchiveTime
+// Description: Function to get when the channel of a resolved incident is
+// 							archived, taking `keep-open` into account
+// ******************************************************************************
+func getArchiveTime(record IncidentRecord, delay time.Duration) time.Time {
+	archiveAt := record.ResolvedAt.Add(delay)
+	if record.KeepOpenUntil.After(archiveAt) {
+		archiveAt = record.KeepOpenUntil
+	}
+	return archiveAt
+}
+
+// ******************************************************************************
+// Name				: archiveResolvedChannels
+// Description: Scheduled job to warn about and archive the channels of resolved
+// 							incidents
+// ******************************************************************************
+func archiveResolvedChannels(now time.Time) {
+	delay, err := getArchiveDelay()
+	if err != nil {
+		return
+	}
+	var warning time.Duration
+	if constants.Slack.Channels.ArchiveWarning != "" {
+		warning, err = time.ParseDuration(constants.Slack.Channels.ArchiveWarning)
+		if err != nil {
+			log.Error("archiveResolvedChannels Invalid Warning: ", constants.Slack.Channels.ArchiveWarning)
+		}
+	}
+
+	records := incidentStore.List(func(record *IncidentRecord) bool {
+		return record.ChannelID != "" && record.Status == "resolved" && !record.ResolvedAt.IsZero() && record.ArchivedAt.IsZero() &&
+			record.ArchiveAttempts < maxArchiveAttempts
+	})
+	for _, record := range records {
+		archiveAt := getArchiveTime(record, delay)
+		if !now.Before(archiveAt) {
+			if now.Before(record.NextArchiveAttemptAt) {
+				continue
+			}
+			archiveIncidentChannel(record)
+		} else if !record.ArchiveWarningSent && !now.Before(archiveAt.Add(-warning)) {
+			warnAboutArchiving(record, archiveAt)
+		}
+	}
+}
+
+// ******************************************************************************
+// Name				: warnAboutArchiving
+// Description: Function to let the incident channel know when it gets archived
+// ******************************************************************************
+func warnAboutArchiving(record IncidentRecord, archiveAt time.Time) {
+	text := "This incident is resolved and this channel will be archived at " + archiveAt.UTC().Format("2006-01-02 15:04 MST") +
+		". The channel history will be attached to the JIRA issue. Use /falcon \"keep-open\" to keep the channel open for longer."
+	_, err := postMessageToChannel(record.ChannelID, text)
+	if err != nil {
+		return
+	}
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.ArchiveWarningSent = true
+	})
+	if err != nil {
+		log.Error("warnAboutArchiving Error: ", err)
+	}
+}
+
+// ******************************************************************************
+// Name				: getSlackMessageTime
+// Description: Function to get the time of a message from its slack timestamp
+// ******************************************************************************
+func getSlackMessageTime(timestamp string) time.Time {
+	seconds, err := strconv.ParseInt(strings.Split(timestamp, ".")[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// ******************************************************************************
+// Name				: archiveIncidentChannel
+// Description: Function to attach the channel history to the JIRA issue of the
+// 							incident and archive the channel. When the transcript was
+// 							attached on resolution, only the messages posted since are
+// 							attached. The channel is kept if the history cannot be
+// 							exported, so that it is retried later
+// ******************************************************************************
+func archiveIncidentChannel(record IncidentRecord) {
+	if record.JiraKey != "" {
+		_, err := attachChannelTranscript(record, record.TranscriptAt)
+		if err != nil {
+			retryArchiving(record, "the channel history could not be attached to "+record.JiraKey+": "+err.Error())
+			return
+		}
+	}
+	err := archiveChannel(record.ChannelID)
+	if err != nil {
+		retryArchiving(record, err.Error())
+		return
+	}
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.ArchivedAt = time.Now()
+	})
+	if err != nil {
+		log.Error("archiveIncidentChannel Error: ", err)
+	}
+	log.Info("Archived incident channel ", record.ChannelID)
+}
+
+// ******************************************************************************
+// Name				: retryArchiving
+// Description: Function to schedule the next attempt to archive the channel of
+// 							an incident with an increasing delay, and to let the channel
+// 							know. Archiving is given up after maxArchiveAttempts attempts
+// ******************************************************************************
+func retryArchiving(record IncidentRecord, reason string) {
+	attempts := record.ArchiveAttempts + 1
+	nextAttemptAt := time.Now().Add(archiveRetryDelay << uint(attempts-1))
+	_, err := incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.ArchiveAttempts = attempts
+		record.NextArchiveAttemptAt = nextAttemptAt
+	})
+	if err != nil {
+		log.Error("retryArchiving Error: ", err)
+	}
+	text := "WARNING!! This channel could not be archived: " + reason + "."
+	if attempts < maxArchiveAttempts {
+		text += " Falcon tries again at " + nextAttemptAt.UTC().Format("2006-01-02 15:04 MST") + "."
+	} else {
+		text += " Falcon gave up after " + strconv.Itoa(attempts) + " attempts, please archive the channel yourself."
+	}
+	postMessageToChannel(record.ChannelID, text)
+}
+
+// ******************************************************************************
+// Name				: keepOpenCommandService
+// Description: Function to postpone archiving the channel of the incident
+// ******************************************************************************
+func keepOpenCommandService(s slack.SlashCommand, arguments []string) {
+	record, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	delay, err := getArchiveDelay()
+	if err != nil {
+		response := SlashResponse{"ephemeral", "ERROR!! The channel cannot be kept open: " + err.Error()}
+		slackCommandResponse(response, s)
+		return
+	}
+	if len(arguments) == 2 {
+		delay, _ = time.ParseDuration(arguments[1])
+	}
+	keepOpenUntil := time.Now().Add(delay)
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.KeepOpenUntil = keepOpenUntil
+		record.ArchiveWarningSent = false
+		record.ArchiveAttempts = 0
+		record.NextArchiveAttemptAt = time.Time{}
+	})
+	if err != nil {
+		msg := "ERROR!! Error updating the incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	responseText := "This channel will not be archived before " + keepOpenUntil.UTC().Format("2006-01-02 15:04 MST")
+	response := SlashResponse{"in_channel", responseText}
+	slackCommandResponse(response, s)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"html"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+const transcriptFormatHTML = "html"
+
+// TranscriptEntry is a message of an incident channel with the replies in its
+// thread
+type TranscriptEntry struct {
+	Message slack.Message
+	Replies []slack.Message
+}
+
+// ******************************************************************************
+// Name				: getChannelTranscript
+// Description: Function to get the messages of a channel in order, together
+// 							with the replies of their threads
+// ******************************************************************************
+func getChannelTranscript(channelID string) ([]TranscriptEntry, error) {
+	messages, err := getChannelHistory(channelID)
+	if err != nil {
+		return nil, err
+	}
+	var entries []TranscriptEntry
+	for _, message := range messages {
+		entry := TranscriptEntry{Message: message}
+		if message.ReplyCount > 0 {
+			// A thread that cannot be loaded still leaves its parent message
+			entry.Replies, _ = getThreadReplies(channelID, message.Timestamp)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ******************************************************************************
+// Name				: getTranscriptAuthor
+// Description: Function to get the name shown for the author of a message
+// ******************************************************************************
+func getTranscriptAuthor(message slack.Message) string {
+	author := message.Username
+	if message.User != "" {
+		author = getSlackUserName(message.User)
+	}
+	if author == "" {
+		author = message.BotID
+	}
+	return author
+}
+
+// ******************************************************************************
+// Name				: getTranscriptFiles
+// Description: Function to get the names and links of the files shared in a
+// 							message
+// ******************************************************************************
+func getTranscriptFiles(message slack.Message) [][2]string {
+	var files [][2]string
+	for _, file := range message.Files {
+		name := file.Title
+		if name == "" {
+			name = file.Name
+		}
+		files = append(files, [2]string{name, file.Permalink})
+	}
+	return files
+}
+
+// ******************************************************************************
+// Name				: formatTranscriptMessage
+// Description: Function to turn a message into a line of the plain text
+// 							transcript
+// ******************************************************************************
+func formatTranscriptMessage(message slack.Message, indent string) string {
+	line := indent + "[" + getSlackMessageTime(message.Timestamp).UTC().Format("2006-01-02 15:04:05 MST") + "] " + getTranscriptAuthor(message) + ": " + message.Text + "\n"
+	for _, file := range getTranscriptFiles(message) {
+		line += indent + "    File: " + file[0] + " " + file[1] + "\n"
+	}
+	return line
+}
+
+// ******************************************************************************
+// Name				: formatChannelTranscript
+// Description: Function to turn the messages of a channel into a plain text
+// 							transcript. Thread replies are indented below their parent
+// ******************************************************************************
+func formatChannelTranscript(entries []TranscriptEntry) string {
+	var transcript strings.Builder
+	for _, entry := range entries {
+		transcript.WriteString(formatTranscriptMessage(entry.Message, ""))
+		for _, reply := range entry.Replies {
+			transcript.WriteString(formatTranscriptMessage(reply, "    "))
+		}
+	}
+	return transcript.String()
+}
+
+// ******************************************************************************
+// Name				: formatTranscriptMessageHTML
+// Description: Function to turn a message into an item of the HTML transcript
+// ******************************************************************************
+func formatTranscriptMessageHTML(message slack.Message) string {
+	item := "<li><span class=\"time\">" + getSlackMessageTime(message.Timestamp).UTC().Format("2006-01-02 15:04:05 MST") + "</span> " +
+		"<b>" + html.EscapeString(getTranscriptAuthor(message)) + "</b>: " +
+		strings.Replace(html.EscapeString(message.Text), "\n", "<br>", -1)
+	for _, file := range getTranscriptFiles(message) {
+		item += "<br>File: <a href=\"" + html.EscapeString(file[1]) + "\">" + html.EscapeString(file[0]) + "</a>"
+	}
+	return item
+}
+
+// ******************************************************************************
+// Name				: formatChannelTranscriptHTML
+// Description: Function to turn the messages of a channel into an HTML
+// 							transcript. Thread replies are nested below their parent
+// ******************************************************************************
+func formatChannelTranscriptHTML(title string, entries []TranscriptEntry) string {
+	var transcript strings.Builder
+	transcript.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>" + html.EscapeString(title) + "</title>" +
+		"<style>body{font-family:sans-serif}.time{color:#888}li{margin:4px 0}</style></head><body>\n")
+	transcript.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n<ul>\n")
+	for _, entry := range entries {
+		transcript.WriteString(formatTranscriptMessageHTML(entry.Message))
+		if len(entry.Replies) > 0 {
+			transcript.WriteString("<ul>\n")
+			for _, reply := range entry.Replies {
+				transcript.WriteString(formatTranscriptMessageHTML(reply) + "</li>\n")
+			}
+			transcript.WriteString("</ul>")
+		}
+		transcript.WriteString("</li>\n")
+	}
+	transcript.WriteString("</ul>\n</body></html>\n")
+	return transcript.String()
+}
+
+// ******************************************************************************
+// Name				: getTranscriptEntriesSince
+// Description: Function to get the messages of a transcript posted after a
+// 							given time. Threads with new replies keep their parent message
+// ******************************************************************************
+func getTranscriptEntriesSince(entries []TranscriptEntry, since time.Time) []TranscriptEntry {
+	var newEntries []TranscriptEntry
+	for _, entry := range entries {
+		if getSlackMessageTime(entry.Message.Timestamp).After(since) {
+			newEntries = append(newEntries, entry)
+			continue
+		}
+		var replies []slack.Message
+		for _, reply := range entry.Replies {
+			if getSlackMessageTime(reply.Timestamp).After(since) {
+				replies = append(replies, reply)
+			}
+		}
+		if len(replies) > 0 {
+			newEntries = append(newEntries, TranscriptEntry{Message: entry.Message, Replies: replies})
+		}
+	}
+	return newEntries
+}
+
+// ******************************************************************************
+// Name				: attachChannelTranscript
+// Description: Function to export the channel of an incident and attach it to
+// 							the JIRA issue of the incident in the format configured in
+// 							jira.transcript_format. Only messages posted after since are
+// 							exported when it is set. Returns the link to the attachment, or
+// 							an empty link when there was nothing to export
+// ******************************************************************************
+func attachChannelTranscript(record IncidentRecord, since time.Time) (string, error) {
+	entries, err := getChannelTranscript(record.ChannelID)
+	if err != nil {
+		return "", err
+	}
+	fileName := "slack-" + record.JiraKey + "-" + time.Now().UTC().Format("20060102")
+	title := record.JiraKey + " " + record.Title
+	if !since.IsZero() {
+		entries = getTranscriptEntriesSince(entries, since)
+		if len(entries) == 0 {
+			return "", nil
+		}
+		fileName += "-after-resolution"
+		title += " (after resolution)"
+	}
+	var content string
+	if constants.JIRA.TranscriptFormat == transcriptFormatHTML {
+		fileName += ".html"
+		content = formatChannelTranscriptHTML(title, entries)
+	} else {
+		fileName += ".txt"
+		content = formatChannelTranscript(entries)
+	}
+	return attachFileToJiraIssue(record.JiraKey, fileName, strings.NewReader(content))
+}
+
+// ******************************************************************************
+// Name				: getResolutionTranscriptLink
+// Description: Function to get the link to the transcript of a resolved
+// 							incident. The first time, the transcript is attached to the
+// 							JIRA issue and the link is posted in the incident channel
+// ******************************************************************************
+func getResolutionTranscriptLink(record IncidentRecord) string {
+	if record.TranscriptURL != "" || record.JiraKey == "" || record.ChannelID == "" {
+		return record.TranscriptURL
+	}
+	exportedAt := time.Now()
+	link, err := attachChannelTranscript(record, time.Time{})
+	if err != nil {
+		return ""
+	}
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.TranscriptURL = link
+		record.TranscriptAt = exportedAt
+	})
+	if err != nil {
+		log.Error("getResolutionTranscriptLink Error: ", err)
+	}
+	postMessageToChannel(record.ChannelID, "The transcript of this channel was attached to "+record.JiraKey+": "+link)
+	return link
+}
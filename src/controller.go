@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
 )
 
 // ******************************************************************************
@@ -34,11 +38,50 @@ func pagerdutyController(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ******************************************************************************
+// Name				: jiraWebhookController
+// Description: Function to process JIRA webhook
+// ******************************************************************************
+func jiraWebhookController(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error("jiraWebhook Read Error: ", err)
+		return
+	}
+	if !isJiraRequestVerified(r, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var payload JiraWebhookPayload
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error("jiraWebhook Parse Error: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	go jiraWebhookService(payload)
+}
+
 // ******************************************************************************
 // Name				: slackController
 // Description: Entrypoint function for handling Slack commands.
 // ******************************************************************************
 func slackController(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error("slackComment Read Error: ", err)
+		return
+	}
+	// Commands may act on any incident, so the user they come from must be
+	// the one Slack signed them for
+	if !isSlackRequestVerified(r.Header, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
 	s, err := slack.SlashCommandParse(r)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -58,7 +101,7 @@ func slackController(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	text := string(runeText)
+	text, reference := splitIncidentArgument(string(runeText))
 	st := strings.Split(text, "\"")
 	var arguments []string
 	for _, j := range st {
@@ -67,6 +110,21 @@ func slackController(w http.ResponseWriter, r *http.Request) {
 			arguments = append(arguments, j)
 		}
 	}
+	if len(arguments) == 0 {
+		slashHelpResponse(s)
+		return
+	}
+	err = resolveCommandIncident(&s, reference)
+	if err == errIncidentAccessDenied {
+		response := SlashResponse{"ephemeral", constants.ValidationMessages.IncidentAccessDenied}
+		slackCommandResponse(response, s)
+		return
+	}
+	if err != nil {
+		response := SlashResponse{"ephemeral", (constants.ValidationMessages.IncidentNotFound + "\n" + constants.ValidationMessages.UseHelp)}
+		slackCommandResponse(response, s)
+		return
+	}
 	resp, err := parseCommandArguments(s, arguments)
 	if err != nil {
 		response := SlashResponse{"ephemeral", resp}
@@ -81,6 +139,118 @@ func slackController(w http.ResponseWriter, r *http.Request) {
 	go slashCommandService(w, s, arguments)
 }
 
+// ******************************************************************************
+// Name				: slackInteractionController
+// Description: Entrypoint function for handling Slack interactivity requests
+// 							like shortcuts, message buttons and modal submissions
+// ******************************************************************************
+func slackInteractionController(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error("slackInteraction Read Error: ", err)
+		return
+	}
+	if !isSlackRequestVerified(r.Header, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error("slackInteraction Parse Error: ", err)
+		return
+	}
+	var callback slack.InteractionCallback
+	err = json.Unmarshal([]byte(form.Get("payload")), &callback)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error("slackInteraction Payload Error: ", err)
+		return
+	}
+
+	switch callback.Type {
+	case slack.InteractionTypeShortcut:
+		if callback.CallbackID == declareCallbackID {
+			go openDeclareModal(callback.TriggerID, DeclareModalMetadata{})
+		}
+	case slack.InteractionTypeBlockActions:
+		for _, action := range callback.ActionCallback.BlockActions {
+			switch action.ActionID {
+			case homeDeclareAction:
+				go openDeclareModal(callback.TriggerID, DeclareModalMetadata{})
+			case homeOpenChannelAction:
+				// The button opens the channel in Slack, there is nothing to do
+			default:
+				go incidentActionService(callback, action)
+			}
+		}
+	case slack.InteractionTypeViewSubmission:
+		var response *slack.ViewSubmissionResponse
+		switch callback.View.CallbackID {
+		case declareCallbackID:
+			response = declareModalSubmission(callback)
+		case updateStatusCallbackID:
+			response = updateStatusModalSubmission(callback)
+		}
+		if response != nil {
+			encode, _ := json.Marshal(response)
+			w.Header().Add("Content-Type", "application/json")
+			w.Write(encode)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ******************************************************************************
+// Name				: slackEventsController
+// Description: Entrypoint function for handling events from the Slack Events API
+// ******************************************************************************
+func slackEventsController(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error("slackEvents Read Error: ", err)
+		return
+	}
+	if !isSlackRequestVerified(r.Header, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	event, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Error("slackEvents Parse Error: ", err)
+		return
+	}
+
+	switch event.Type {
+	case slackevents.URLVerification:
+		var challenge slackevents.ChallengeResponse
+		err = json.Unmarshal(body, &challenge)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Add("Content-Type", "text/plain")
+		w.Write([]byte(challenge.Challenge))
+		return
+	case slackevents.CallbackEvent:
+		switch innerEvent := event.InnerEvent.Data.(type) {
+		case *slackevents.AppHomeOpenedEvent:
+			if innerEvent.Tab == "home" {
+				go publishHomeTab(innerEvent.User)
+			}
+		case *slackevents.MessageEvent:
+			go mirrorMessageEvent(innerEvent)
+		case *slackevents.ReactionAddedEvent:
+			go mirrorReactionEvent(innerEvent)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // ******************************************************************************
 // Name				: updateConfigController
 // Description: Function to update config
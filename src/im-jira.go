@@ -1,55 +1,38 @@
 package main
 
 import (
-	"os"
+	"io"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/andygrunwald/go-jira"
 	log "github.com/sirupsen/logrus"
-	"github.com/trivago/tgo/tcontainer"
 )
 
-type TransitionResponse struct {
-	Transitions []jira.Transition `json:"transitions" structs:"transitions"`
-}
-
-type PostReqTransitionObject struct {
-	Transition PostReqTransition `json:"transition"`
-	Fields     PostReqFields     `json:"fields"`
-}
-
-type PostReqTransition struct {
-	ID string `json:"id"`
-}
-
-type PostReqFields struct {
-	Resolution PostReqResolution `json:"resolution"`
-}
-
-type PostReqResolution struct {
-	Name string `json:"name"`
+// jiraClientCache is the JIRA client shared by all requests
+var jiraClientCache struct {
+	sync.Mutex
+	client *jira.Client
+	key    string
 }
 
 // ******************************************************************************
 // Name				: createJiraIssue
-// Description: Function to create JIRA issue ticket
+// Description: Function to create JIRA issue ticket with the fields mapped in
+// 							jira.fields
 // ******************************************************************************
-func createJiraIssue(summary string, user ...User) (*jira.Issue, error) {
-	jiraClient := getJIRAClient()
-	customFields := tcontainer.NewMarshalMap()
-	customFields["customfield_15201"] = time.Now().Format(time.RFC3339)
+func createJiraIssue(data JiraIssueData) (*jira.Issue, error) {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return nil, err
+	}
+	if data.StartedAt.IsZero() {
+		data.StartedAt = time.Now()
+	}
 	i := jira.Issue{
-		Fields: &jira.IssueFields{
-			Type: jira.IssueType{
-				ID: constants.JIRA.IssueTypeID, // To set issue type as incident
-			},
-			Project: jira.Project{
-				ID: constants.JIRA.ProjectID, // To set project
-			},
-			Summary:  summary,
-			Unknowns: customFields,
-		},
+		Fields: getJiraIssueFields(data),
 	}
 	issue, _, err := jiraClient.Issue.Create(&i)
 	if err != nil {
@@ -61,96 +44,334 @@ func createJiraIssue(summary string, user ...User) (*jira.Issue, error) {
 
 // ******************************************************************************
 // Name				: addComment
-// Description: Function to add comment to JIRA Ticket
+// Description: Function to add comment of a slack user to JIRA Ticket
 // ******************************************************************************
-func addComment(url string, user string, text string, status string) (*jira.Comment, *jira.Response, error) {
-	jiraClient := getJIRAClient()
-	var comment *jira.Comment
-	var resp *jira.Response
-	c := jira.Comment{
-		Body: text,
-	}
-	userEmail := user + "@olx.com"
-	jiraUser, _, err := jiraClient.User.Find(userEmail)
+func addComment(url string, userID string, text string) (*jira.Comment, *jira.Response, error) {
+	jiraClient, err := getJIRAClient()
 	if err != nil {
-		log.Error("JIRA user not found", err)
-		return comment, resp, err
-	} else {
-		c.Author = jira.User{
-			AccountID: jiraUser[0].AccountID,
-		}
+		return nil, nil, err
 	}
-	c.Body = user + ": " + text
+	c := getJiraCommentForSlackUser(userID, text)
 	url = strings.Trim(url, "<>")
 	urlSplit := strings.Split(url, "/")
 	issueID := urlSplit[len(urlSplit)-1]
-	comment, resp, err = jiraClient.Issue.AddComment(issueID, &c)
+	comment, resp, err := jiraClient.Issue.AddComment(issueID, &c)
 	if err != nil {
 		log.Error("Error in commenting on JIRA issue: ", err)
 		return comment, resp, err
 	}
-	if status == "close" {
-		err = changeJIRATicketStatus(issueID)
-	}
+	rememberFalconJiraComment(comment.ID)
 	return comment, resp, err
 }
 
+// ******************************************************************************
+// Name				: findJiraUser
+// Description: Function to find the JIRA user with an email. Only users with
+// 							exactly this email are returned, unless the email was
+// 							configured as alias and JIRA finds a single user for it
+// ******************************************************************************
+func findJiraUser(email string, configured bool) (jira.User, error) {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return jira.User{}, err
+	}
+	users, _, err := jiraClient.User.Find(url.QueryEscape(email))
+	if err != nil {
+		log.Error("findJiraUser Error: ", email, " ", err)
+		return jira.User{}, err
+	}
+	for _, user := range users {
+		if strings.EqualFold(user.EmailAddress, email) {
+			return user, nil
+		}
+	}
+	// JIRA Cloud hides emails of users by default, so a single match is only
+	// trusted for emails configured in jira.users.email_aliases
+	if configured && len(users) == 1 {
+		return users[0], nil
+	}
+	return jira.User{}, errJiraUserNotFound
+}
+
+// ******************************************************************************
+// Name				: addJiraIssueComment
+// Description: Function to add a comment to a JIRA issue as the Falcon user
+// ******************************************************************************
+func addJiraIssueComment(issueKey string, body string) (string, error) {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return "", err
+	}
+	comment, _, err := jiraClient.Issue.AddComment(issueKey, &jira.Comment{Body: body})
+	if err != nil {
+		log.Error("addJiraIssueComment Error: ", err)
+		return "", err
+	}
+	rememberFalconJiraComment(comment.ID)
+	return comment.ID, nil
+}
+
+// ******************************************************************************
+// Name				: updateJiraIssueComment
+// Description: Function to change the text of a comment of a JIRA issue
+// ******************************************************************************
+func updateJiraIssueComment(issueKey string, commentID string, body string) error {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return err
+	}
+	_, _, err = jiraClient.Issue.UpdateComment(issueKey, &jira.Comment{ID: commentID, Body: body})
+	if err != nil {
+		log.Error("updateJiraIssueComment Error: ", err)
+	}
+	return err
+}
+
+// ******************************************************************************
+// Name				: deleteJiraIssueComment
+// Description: Function to remove a comment from a JIRA issue
+// ******************************************************************************
+func deleteJiraIssueComment(issueKey string, commentID string) error {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return err
+	}
+	err = jiraClient.Issue.DeleteComment(issueKey, commentID)
+	if err != nil {
+		log.Error("deleteJiraIssueComment Error: ", err)
+	}
+	return err
+}
+
+// ******************************************************************************
+// Name				: attachFileToJiraIssue
+// Description: Function to upload a file as attachment of a JIRA issue and get
+// 							the link to it
+// ******************************************************************************
+func attachFileToJiraIssue(issueKey string, fileName string, content io.Reader) (string, error) {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return "", err
+	}
+	attachments, _, err := jiraClient.Issue.PostAttachment(issueKey, content, fileName)
+	if err != nil {
+		log.Error("attachFileToJiraIssue Error: ", err)
+		return "", err
+	}
+	if attachments == nil || len(*attachments) == 0 {
+		return constants.JIRA.Endpoint + "/browse/" + issueKey, nil
+	}
+	return (*attachments)[0].Content, nil
+}
+
 // ******************************************************************************
 // Name				: getJIRAClient
-// Description: Function to get JIRA Client Object
+// Description: Function to get JIRA Client Object. The client is created once
+// 							and only created again when the endpoint or jira.auth change.
+// 							Clients whose credentials cannot be set up are not kept, so
+// 							they are set up again on the next request
 // ******************************************************************************
-func getJIRAClient() *jira.Client {
-	base := constants.JIRA.Endpoint
-	tp := jira.BasicAuthTransport{
-		Username: os.Getenv("JIRA_USERNAME"),
-		Password: os.Getenv("JIRA_PASSWORD"),
+func getJIRAClient() (*jira.Client, error) {
+	jiraClientCache.Lock()
+	defer jiraClientCache.Unlock()
+	key := constants.JIRA.Endpoint + "|" + constants.JIRA.Auth
+	if jiraClientCache.client != nil && jiraClientCache.key == key {
+		return jiraClientCache.client, nil
+	}
+	httpClient, err := getJiraHTTPClient(constants.JIRA.Auth)
+	if err != nil {
+		log.Error("getJIRAClient Authentication Error: ", err)
+		return nil, err
 	}
-	jiraClient, err := jira.NewClient(tp.Client(), base)
+	jiraClient, err := jira.NewClient(httpClient, constants.JIRA.Endpoint)
 	if err != nil {
 		log.Error("getJIRAClient Error: ", err)
+		return nil, err
 	}
-	return jiraClient
+	jiraClientCache.client = jiraClient
+	jiraClientCache.key = key
+	return jiraClient, nil
 }
 
 // ******************************************************************************
-// Name				: getJIRAClient
-// Description: Function to change JIRA Ticket Status
+// Name				: jiraInitializer
+// Description: Function to check at startup that Falcon can reach and
+// 							authenticate with JIRA. Falcon still starts when JIRA is
+// 							unreachable, so that PagerDuty and Slack keep working
 // ******************************************************************************
-func changeJIRATicketStatus(issueId string) error {
-	jiraClient := getJIRAClient()
+func jiraInitializer() {
+	self, err := getJiraSelf()
+	if err != nil {
+		log.Error("jiraInitializer Error: Falcon could not connect to JIRA at ", constants.JIRA.Endpoint, " with jira.auth \"", constants.JIRA.Auth, "\": ", err)
+		return
+	}
+	falconJiraUser.Lock()
+	falconJiraUser.user = self
+	falconJiraUser.Unlock()
+	log.Info("Connected to JIRA as ", getJiraUserDisplayName(self))
+}
 
-	// Get Transition Id for Close Transition
-	transitionReq, _ := jiraClient.NewRequest("GET", "rest/api/latest/issue/"+issueId+"/transitions?expand=transitions.fields", nil)
-	transitions := new(TransitionResponse)
-	_, err := jiraClient.Do(transitionReq, transitions)
+// ******************************************************************************
+// Name				: createJiraActionItem
+// Description: Function to create the JIRA issue of an action item of an
+// 							incident, assigned to the JIRA user of its owner when found
+// ******************************************************************************
+func createJiraActionItem(record IncidentRecord, item ActionItem) (*jira.Issue, error) {
+	jiraClient, err := getJIRAClient()
 	if err != nil {
-		log.Error("JIRA TransitionRequest Error: ", err)
-		return err
+		return nil, err
+	}
+	projectID := constants.JIRA.Actions.ProjectID
+	if projectID == "" {
+		projectID = constants.JIRA.ProjectID
+	}
+	issueTypeID := constants.JIRA.Actions.IssueTypeID
+	if issueTypeID == "" {
+		issueTypeID = constants.JIRA.IssueTypeID
 	}
-	var transitionID string
-	for _, transition := range *&transitions.Transitions {
-		if strings.ToLower(transition.Name) == "close" {
-			log.Debug(transition.ID, " : ", transition.Name)
-			transitionID = transition.ID
+	description := "Follow-up of incident " + record.JiraKey + ": " + record.Title
+	fields := &jira.IssueFields{
+		Type:        jira.IssueType{ID: issueTypeID},
+		Project:     jira.Project{ID: projectID},
+		Summary:     item.Summary,
+		Description: description,
+	}
+	if item.Due != "" {
+		due, err := time.Parse("2006-01-02", item.Due)
+		if err == nil {
+			fields.Duedate = jira.Date(due)
+		}
+	}
+	if item.OwnerID != "" {
+		owner, err := getJiraUserForSlackUser(item.OwnerID)
+		if err == nil {
+			fields.Assignee = &jira.User{AccountID: owner.AccountID, Name: owner.Name}
+		} else {
+			fields.Description += "\nOwner: " + getSlackUserName(item.OwnerID)
 		}
 	}
+	issue, _, err := jiraClient.Issue.Create(&jira.Issue{Fields: fields})
+	if err != nil {
+		log.Error("createJiraActionItem Error: ", err)
+	}
+	return issue, err
+}
 
-	// Mark Incident as Done and close Ticket
-	postData := PostReqTransitionObject{
-		Transition: PostReqTransition{
-			ID: transitionID,
-		},
-		Fields: PostReqFields{
-			Resolution: PostReqResolution{
-				Name: "Done",
-			},
-		},
+// ******************************************************************************
+// Name				: linkJiraIssues
+// Description: Function to link two JIRA issues with the given link type
+// ******************************************************************************
+func linkJiraIssues(inwardKey string, outwardKey string, linkType string) error {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return err
+	}
+	_, err = jiraClient.Issue.AddLink(&jira.IssueLink{
+		Type:         jira.IssueLinkType{Name: linkType},
+		InwardIssue:  &jira.Issue{Key: inwardKey},
+		OutwardIssue: &jira.Issue{Key: outwardKey},
+	})
+	if err != nil {
+		log.Error("linkJiraIssues Error: ", err)
+	}
+	return err
+}
+
+// ******************************************************************************
+// Name				: getJiraIssueStatus
+// Description: Function to get the status of a JIRA issue and whether it is in
+// 							a done status category
+// ******************************************************************************
+func getJiraIssueStatus(issueKey string) (string, bool, error) {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return "", false, err
 	}
-	statusUpdateReq, _ := jiraClient.NewRequest("POST", "rest/api/2/issue/"+issueId+"/transitions", postData)
-	_, err = jiraClient.Do(statusUpdateReq, nil)
+	issue, _, err := jiraClient.Issue.Get(issueKey, &jira.GetQueryOptions{Fields: "status"})
+	if err != nil {
+		log.Error("getJiraIssueStatus Error: ", err)
+		return "", false, err
+	}
+	if issue.Fields == nil || issue.Fields.Status == nil {
+		return "", false, nil
+	}
+	status := issue.Fields.Status
+	return status.Name, status.StatusCategory.Key == "done", nil
+}
+
+// ******************************************************************************
+// Name				: searchJiraIssues
+// Description: Function to find JIRA issues with a JQL query
+// ******************************************************************************
+func searchJiraIssues(jql string, maxResults int) ([]jira.Issue, error) {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return nil, err
+	}
+	issues, _, err := jiraClient.Issue.Search(jql, &jira.SearchOptions{
+		MaxResults: maxResults,
+		Fields:     []string{"summary", "created"},
+	})
+	if err != nil {
+		log.Error("searchJiraIssues Error: ", err)
+	}
+	return issues, err
+}
+
+// ******************************************************************************
+// Name				: getJiraSelf
+// Description: Function to get the JIRA user Falcon is authenticated as
+// ******************************************************************************
+func getJiraSelf() (*jira.User, error) {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return nil, err
+	}
+	user, _, err := jiraClient.User.GetSelf()
+	if err != nil {
+		log.Error("getJiraSelf Error: ", err)
+	}
+	return user, err
+}
+
+// ******************************************************************************
+// Name				: getJiraTransitions
+// Description: Function to get the transitions a JIRA issue can take from its
+// 							current status
+// ******************************************************************************
+func getJiraTransitions(issueKey string) ([]jira.Transition, error) {
+	jiraClient, err := getJIRAClient()
+	if err != nil {
+		return nil, err
+	}
+	transitions, _, err := jiraClient.Issue.GetTransitions(issueKey)
+	if err != nil {
+		log.Error("getJiraTransitions Error: ", err)
+	}
+	return transitions, err
+}
+
+// ******************************************************************************
+// Name				: doJiraTransition
+// Description: Function to move a JIRA issue through a transition, setting the
+// 							resolution when one is given
+// ******************************************************************************
+func doJiraTransition(issueKey string, transitionID string, resolution string) error {
+	jiraClient, err := getJIRAClient()
 	if err != nil {
-		log.Error("Error occurred while closing JIRA Ticket(" + issueId + ")")
 		return err
 	}
+	payload := map[string]interface{}{
+		"transition": map[string]string{"id": transitionID},
+	}
+	if resolution != "" {
+		payload["fields"] = map[string]interface{}{
+			"resolution": map[string]string{"name": resolution},
+		}
+	}
+	_, err = jiraClient.Issue.DoTransitionWithPayload(issueKey, payload)
+	if err != nil {
+		log.Error("Error occurred while moving JIRA Ticket(" + issueKey + ")")
+	}
 	return err
 }
@@ -2,12 +2,26 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// Number of entries requested per page from pagerduty list endpoints
+const pdPageLimit = 100
+
+// PDListPage holds the pagination fields of pagerduty list responses
+type PDListPage struct {
+	Limit  int  `json:"limit"`
+	Offset int  `json:"offset"`
+	More   bool `json:"more"`
+}
+
 // ******************************************************************************
 // Name				: callPagerDuty
 // Description: Helper function to prepare call to pagerduty api
@@ -15,6 +29,10 @@ import (
 func callPagerDuty(url string) (*http.Response, error) {
 	var Authorization = "Token token=" + os.Getenv("PAGERDUTY_ACCESS_TOKEN")
 	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		log.Error("callPagerDuty Error: ", err)
+		return nil, err
+	}
 	req.Header.Add("Authorization", Authorization)
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -25,20 +43,85 @@ func callPagerDuty(url string) (*http.Response, error) {
 }
 
 // ******************************************************************************
-// Name				: getPDUser
-// Description: Function to get pagerduty user details
+// Name				: getPDIncidentAssignees
+// Description: Function to get the users assigned to a pagerduty incident with
+// 							a single request. The references in the webhook have no email
+// 							so they are only returned when the request fails
+// ******************************************************************************
+func getPDIncidentAssignees(incident Incident) []User {
+	var users []User
+	for _, j := range incident.Assignments {
+		users = append(users, User{ID: j.Assignee.ID, Summary: j.Assignee.Summary})
+	}
+	url := incident.Self
+	if url == "" {
+		url = "https://api.pagerduty.com/incidents/" + incident.ID
+	}
+	resp, err := callPagerDuty(url + "?include[]=assignees")
+	if err != nil {
+		return users
+	}
+	defer resp.Body.Close()
+	var wrapper struct {
+		Incident struct {
+			Assignments []struct {
+				Assignee User `json:"assignee"`
+			} `json:"assignments"`
+		} `json:"incident"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&wrapper)
+	if err != nil {
+		log.Error("getPDIncidentAssignees parsing Error: ", err)
+		return users
+	}
+	users = nil
+	for _, j := range wrapper.Incident.Assignments {
+		users = append(users, j.Assignee)
+	}
+	return users
+}
+
+// ******************************************************************************
+// Name				: loadPDList
+// Description: Function to go through all pages of a pagerduty list endpoint.
+// 							The body of every page is passed to handle
 // ******************************************************************************
-func getPDUser(url string) User {
-	resp, err := callPagerDuty(url)
-	if err == nil {
-		user := new(UserWrapper)
-		err = json.NewDecoder(resp.Body).Decode(&user)
+func loadPDList(url string, handle func(body []byte) error) error {
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+	offset := 0
+	for {
+		resp, err := callPagerDuty(url + separator + "limit=" + strconv.Itoa(pdPageLimit) + "&offset=" + strconv.Itoa(offset))
+		if err != nil {
+			return err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			log.Error("loadPDList Read Error: ", err)
+			return err
+		}
+		if resp.StatusCode >= 300 {
+			log.Error("loadPDList Error: ", resp.Status, " ", string(body))
+			return errors.New("PagerDuty responded with " + resp.Status)
+		}
+		var page PDListPage
+		err = json.Unmarshal(body, &page)
 		if err != nil {
-			log.Error("pdUser parsing Error: ", err)
+			log.Error("loadPDList parsing Error: ", err)
+			return err
 		}
-		return *user.User
+		err = handle(body)
+		if err != nil {
+			return err
+		}
+		if !page.More || page.Limit <= 0 {
+			return nil
+		}
+		offset = page.Offset + page.Limit
 	}
-	return User{}
 }
 
 // ******************************************************************************
@@ -46,30 +129,51 @@ func getPDUser(url string) User {
 // Description: Function to get team members from pagerduty team
 // ******************************************************************************
 func loadPDTeamMembers(url string) TeamMembers {
-	resp, err := callPagerDuty(url)
-	if err == nil {
-		var memberList TeamMembers
-		err = json.NewDecoder(resp.Body).Decode(&memberList)
+	var memberList TeamMembers
+	err := loadPDList(url+"?include[]=users", func(body []byte) error {
+		var page TeamMembers
+		err := json.Unmarshal(body, &page)
 		if err != nil {
 			log.Error("loadPDTeamMembers parsing Error: ", err)
+			return err
 		}
-		return memberList
+		memberList.Members = append(memberList.Members, page.Members...)
+		return nil
+	})
+	if err != nil {
+		log.Error("loadPDTeamMembers Error: ", err)
 	}
-	return TeamMembers{}
+	return memberList
 }
 
 // ******************************************************************************
-// Name				: getOnCall
-// Description: Function to get on call user
+// Name				: getOnCallUsers
+// Description: Function to get the users on call for an escalation policy. Only
+// 							users of the given escalation level are returned, or users
+// 							of all levels when the level is 0
 // ******************************************************************************
-func getOnCall(EscalationPolicy string) User {
-	url := "https://api.pagerduty.com/oncalls?escalation_policy_ids[]=" + EscalationPolicy
-	resp, err := callPagerDuty(url)
-	if err == nil {
+func getOnCallUsers(escalationPolicy string, level int) []User {
+	var users []User
+	seen := map[string]bool{}
+	url := "https://api.pagerduty.com/oncalls?escalation_policy_ids[]=" + escalationPolicy + "&include[]=users"
+	err := loadPDList(url, func(body []byte) error {
 		var oncalls OncallWrapper
-		json.NewDecoder(resp.Body).Decode(&oncalls)
-		oncallUser := getPDUser("https://api.pagerduty.com/users/" + oncalls.Oncalls[0].User.ID)
-		return oncallUser
+		err := json.Unmarshal(body, &oncalls)
+		if err != nil {
+			log.Error("getOnCallUsers parsing Error: ", err)
+			return err
+		}
+		for _, j := range oncalls.Oncalls {
+			if (level > 0 && j.EscalationLevel != level) || j.User.ID == "" || seen[j.User.ID] {
+				continue
+			}
+			seen[j.User.ID] = true
+			users = append(users, j.User)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("getOnCallUsers Error: ", err)
 	}
-	return User{}
+	return users
 }
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
 
@@ -10,37 +14,27 @@ import (
 
 // ******************************************************************************
 // Name				: createNewChannel
-// Description: Function to create slack channel and add members to it
+// Description: Function to create slack channel and add members to it. When
+// 							the name is taken a numbered suffix is added to it
 // ******************************************************************************
-func createNewChannel(channelName string, users []User) (*slack.Channel, error) {
+func createNewChannel(channelName string, isPrivate bool, users []User) (*slack.Channel, error) {
 	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
-	channel, err := slackAPI.CreateConversation(channelName, false)
+	channel, err := slackAPI.CreateConversation(channelName, isPrivate)
+	for attempt := 2; err != nil && err.Error() == "name_taken" && attempt <= maxChannelNameAttempts; attempt++ {
+		channel, err = slackAPI.CreateConversation(getAlternativeChannelName(channelName, attempt), isPrivate)
+	}
 	if err != nil {
 		log.Error("Slack channel creation Error:", err)
 		return channel, err
 	}
 
 	if len(users) > 0 {
-		userIDList := []string{}
-
-		// Get users in Slack by email
-		for _, j := range users {
-			user, err := slackAPI.GetUserByEmail(j.Email)
-			if err != nil {
-				log.Error("Slack get user by email Error: ", err)
-				return channel, err
-			}
-			userIDList = append(userIDList, user.ID)
-		}
-
-		// Invite users to Incident channnel
-		channel, err = slackAPI.InviteUsersToConversation(channel.ID, userIDList...)
-		if err != nil {
-			log.Error("Slack add user to incident channel Error: ", err)
-			return channel, err
-		}
+		// Users who cannot be found or invited must not keep the others out
+		userIDList, failures := resolveSlackUsers(users)
+		failures = append(failures, inviteUsersBestEffort(channel.ID, userIDList)...)
+		postInviteSummary(channel.ID, failures)
 	}
-	return channel, err
+	return channel, nil
 }
 
 // ******************************************************************************
@@ -75,25 +69,488 @@ func getChannelPurpose(channelID string) (string, error) {
 // ******************************************************************************
 // Name				: postMessageToSlackChannel
 // Description: Function to post custom message about incident to other slack
-// 							channels
+// 							channels. A channel that cannot be posted to does not keep
+// 							the others from getting the message
+// ******************************************************************************
+func postMessageToSlackChannel(record IncidentRecord, channelIDs []string) []SlackMessageRef {
+	var messages []SlackMessageRef
+	if len(channelIDs) == 0 {
+		log.Info("No Channels configured for posting alerts")
+		return messages
+	}
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	messageText := "Incident Alert: " + record.Title
+	for _, channelID := range channelIDs {
+		channel, timestamp, err := slackAPI.PostMessage(channelID, slack.MsgOptionText(messageText, false), slack.MsgOptionBlocks(getIncidentMessageBlocks(record)...))
+		if err != nil {
+			log.Error("postMessage Error: ", channelID, " ", err)
+			continue
+		}
+		log.Info("Message successfully sent to channel ", channel, " at ", timestamp)
+		messages = append(messages, SlackMessageRef{ChannelID: channel, Timestamp: timestamp})
+	}
+	return messages
+}
+
+// ******************************************************************************
+// Name				: postThreadReply
+// Description: Function to reply in the thread of a message. Broadcast replies
+// 							are also shown in the channel
+// ******************************************************************************
+func postThreadReply(message SlackMessageRef, text string, broadcast bool) error {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	options := []slack.MsgOption{slack.MsgOptionText(text, false), slack.MsgOptionTS(message.Timestamp)}
+	if broadcast {
+		options = append(options, slack.MsgOptionBroadcast())
+	}
+	_, _, err := slackAPI.PostMessage(message.ChannelID, options...)
+	if err != nil {
+		log.Error("postThreadReply Error: ", message.ChannelID, " ", err)
+	}
+	return err
+}
+
+// ******************************************************************************
+// Name				: updateSlackMessage
+// Description: Function to replace the content of a message posted by Falcon
+// ******************************************************************************
+func updateSlackMessage(message SlackMessageRef, text string, blocks []slack.Block) error {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	_, _, _, err := slackAPI.UpdateMessage(message.ChannelID, message.Timestamp, slack.MsgOptionText(text, false), slack.MsgOptionBlocks(blocks...))
+	if err != nil {
+		log.Error("updateSlackMessage Error: ", err)
+	}
+	return err
+}
+
+// ******************************************************************************
+// Name				: inviteUsersToChannel
+// Description: Function to add slack users to a channel
+// ******************************************************************************
+func inviteUsersToChannel(channelID string, userIDs []string) error {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	_, err := slackAPI.InviteUsersToConversation(channelID, userIDs...)
+	if err != nil && err.Error() != "already_in_channel" {
+		log.Error("inviteUsersToChannel Error: ", err)
+		return err
+	}
+	return nil
+}
+
+// ******************************************************************************
+// Name				: getChannelMembers
+// Description: Function to get the IDs of the members of a slack channel
+// ******************************************************************************
+func getChannelMembers(channelID string) ([]string, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	var members []string
+	params := slack.GetUsersInConversationParameters{ChannelID: channelID, Limit: 200}
+	for {
+		page, cursor, err := slackAPI.GetUsersInConversation(&params)
+		if err != nil {
+			log.Error("getChannelMembers Error: ", err)
+			return nil, err
+		}
+		members = append(members, page...)
+		if cursor == "" {
+			break
+		}
+		params.Cursor = cursor
+	}
+	return members, nil
+}
+
+// ******************************************************************************
+// Name				: getChannelIDByName
+// Description: Function to find a public or private slack channel by its name
+// ******************************************************************************
+func getChannelIDByName(name string) (string, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	params := slack.GetConversationsParameters{Types: []string{"public_channel", "private_channel"}, Limit: 200, ExcludeArchived: "true"}
+	for {
+		channels, cursor, err := slackAPI.GetConversations(&params)
+		if err != nil {
+			log.Error("getChannelIDByName Error: ", err)
+			return "", err
+		}
+		for _, channel := range channels {
+			if channel.Name == name {
+				return channel.ID, nil
+			}
+		}
+		if cursor == "" {
+			return "", errors.New("channel_not_found")
+		}
+		params.Cursor = cursor
+	}
+}
+
+// ******************************************************************************
+// Name				: getSlackBotUserID
+// Description: Function to get the slack user Falcon posts as
+// ******************************************************************************
+func getSlackBotUserID() (string, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	auth, err := slackAPI.AuthTest()
+	if err != nil {
+		log.Error("getSlackBotUserID Error: ", err)
+		return "", err
+	}
+	return auth.UserID, nil
+}
+
+// ******************************************************************************
+// Name				: getUserGroupMembers
+// Description: Function to get the IDs of the members of a slack user group
+// ******************************************************************************
+func getUserGroupMembers(userGroupID string) ([]string, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	members, err := slackAPI.GetUserGroupMembers(userGroupID)
+	if err != nil {
+		log.Error("getUserGroupMembers Error: ", err)
+	}
+	return members, err
+}
+
+// ******************************************************************************
+// Name				: getSlackUserIDByEmail
+// Description: Function to find the slack user with the given email. Emails
+// 							from slack.email_aliases are looked up by their alias and
+// 							resolved IDs are cached
+// ******************************************************************************
+func getSlackUserIDByEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	for pagerDutyEmail, slackEmail := range constants.Slack.EmailAliases {
+		if strings.EqualFold(pagerDutyEmail, email) {
+			email = slackEmail
+			break
+		}
+	}
+	email = strings.ToLower(email)
+	if userID, ok := slackUserIDCache.Load(email); ok {
+		return userID.(string), nil
+	}
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	user, err := slackAPI.GetUserByEmail(email)
+	if err != nil {
+		log.Error("getSlackUserIDByEmail Error: ", email, " ", err)
+		return "", err
+	}
+	slackUserIDCache.Store(email, user.ID)
+	return user.ID, nil
+}
+
+// ******************************************************************************
+// Name				: getSlackUserIDByName
+// Description: Function to get the ID of a slack user by its username or
+// 							display name
+// ******************************************************************************
+func getSlackUserIDByName(name string) (string, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	users, err := slackAPI.GetUsers()
+	if err != nil {
+		log.Error("getSlackUserIDByName Error: ", err)
+		return "", err
+	}
+	for _, user := range users {
+		if !user.Deleted && (strings.EqualFold(user.Name, name) || strings.EqualFold(user.Profile.DisplayName, name)) {
+			return user.ID, nil
+		}
+	}
+	return "", errors.New("users_not_found")
+}
+
+// ******************************************************************************
+// Name				: getUserGroups
+// Description: Function to get the slack user groups of the workspace
+// ******************************************************************************
+func getUserGroups() ([]slack.UserGroup, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	userGroups, err := slackAPI.GetUserGroups()
+	if err != nil {
+		log.Error("getUserGroups Error: ", err)
+	}
+	return userGroups, err
+}
+
+// ******************************************************************************
+// Name				: postMessageToChannel
+// Description: Function to post a message to a slack channel
+// ******************************************************************************
+func postMessageToChannel(channelID string, text string) (string, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	_, timestamp, err := slackAPI.PostMessage(channelID, slack.MsgOptionText(text, false))
+	if err != nil {
+		log.Error("postMessageToChannel Error: ", err)
+	}
+	return timestamp, err
+}
+
+// ******************************************************************************
+// Name				: publishView
+// Description: Function to publish the App Home tab of a user
+// ******************************************************************************
+func publishView(userID string, view slack.HomeTabViewRequest) error {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	_, err := slackAPI.PublishView(userID, view, "")
+	return err
+}
+
+// ******************************************************************************
+// Name				: postBlocksToChannel
+// Description: Function to post a Block Kit message to a slack channel
+// ******************************************************************************
+func postBlocksToChannel(channelID string, text string, blocks []slack.Block) (string, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	_, timestamp, err := slackAPI.PostMessage(channelID, slack.MsgOptionText(text, false), slack.MsgOptionBlocks(blocks...))
+	if err != nil {
+		log.Error("postBlocksToChannel Error: ", err)
+	}
+	return timestamp, err
+}
+
+// ******************************************************************************
+// Name				: openModal
+// Description: Function to open a modal for the user who triggered the action
+// ******************************************************************************
+func openModal(triggerID string, modal slack.ModalViewRequest) error {
+	_, err := openModalView(triggerID, modal)
+	return err
+}
+
+// ******************************************************************************
+// Name				: openModalView
+// Description: Function to open a modal and get the opened view, so that it can
+// 							be updated later on
+// ******************************************************************************
+func openModalView(triggerID string, modal slack.ModalViewRequest) (*slack.View, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	response, err := slackAPI.OpenView(triggerID, modal)
+	if err != nil {
+		log.Error("openModal Error: ", err)
+		return nil, err
+	}
+	return &response.View, nil
+}
+
 // ******************************************************************************
-func postMessageToSlackChannel(channelID string, title string) {
-	if constants.Slack.NotificationChannelIDs != "" {
-		slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
-		attachment := slack.Attachment{
-			Text: "All relevant members are requested to join the group <#" + channelID + ">",
+// Name				: updateModal
+// Description: Function to replace the content of an open modal. The hash keeps
+// 							the modal from being overwritten if it changed in between
+// ******************************************************************************
+func updateModal(view *slack.View, modal slack.ModalViewRequest) error {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	_, err := slackAPI.UpdateView(modal, "", view.Hash, view.ID)
+	if err != nil {
+		log.Error("updateModal Error: ", err)
+	}
+	return err
+}
+
+// ******************************************************************************
+// Name				: getChannelHistory
+// Description: Function to get all messages of a slack channel, oldest first
+// ******************************************************************************
+func getChannelHistory(channelID string) ([]slack.Message, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	var messages []slack.Message
+	params := slack.GetConversationHistoryParameters{ChannelID: channelID, Limit: 200}
+	for {
+		history, err := slackAPI.GetConversationHistory(&params)
+		if err != nil {
+			log.Error("getChannelHistory Error: ", err)
+			return nil, err
 		}
-		messageText := "Incident Alert: " + title
-		channelsIDs := strings.Split(constants.Slack.NotificationChannelIDs, ",")
-		for i := 0; i < len(channelsIDs); i++ {
-			channelID := channelsIDs[i]
-			channel, timestamp, err := slackAPI.PostMessage(channelID, slack.MsgOptionText(messageText, false), slack.MsgOptionAttachments(attachment))
-			if err != nil {
-				log.Error("postMessage Error: ", err)
-				return
+		messages = append(messages, history.Messages...)
+		if !history.HasMore || history.ResponseMetaData.NextCursor == "" {
+			break
+		}
+		params.Cursor = history.ResponseMetaData.NextCursor
+	}
+	// Slack returns the newest messages first
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// ******************************************************************************
+// Name				: getThreadReplies
+// Description: Function to get the replies in the thread of a message, oldest
+// 							first
+// ******************************************************************************
+func getThreadReplies(channelID string, timestamp string) ([]slack.Message, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	var replies []slack.Message
+	params := slack.GetConversationRepliesParameters{ChannelID: channelID, Timestamp: timestamp, Limit: 200}
+	for {
+		messages, hasMore, cursor, err := slackAPI.GetConversationReplies(&params)
+		if err != nil {
+			log.Error("getThreadReplies Error: ", err)
+			return nil, err
+		}
+		for _, message := range messages {
+			// The parent message is returned with every page
+			if message.Timestamp != timestamp {
+				replies = append(replies, message)
 			}
-			log.Info("Message successfully sent to channel ", channel, " at ", timestamp)
 		}
+		if !hasMore || cursor == "" {
+			break
+		}
+		params.Cursor = cursor
+	}
+	return replies, nil
+}
+
+// ******************************************************************************
+// Name				: getChannelMessage
+// Description: Function to get a single message of a slack channel. Messages
+// 							of the channel are read from its history and thread replies
+// 							from their thread
+// ******************************************************************************
+func getChannelMessage(channelID string, timestamp string) (slack.Message, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	history, err := slackAPI.GetConversationHistory(&slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Oldest:    timestamp,
+		Latest:    timestamp,
+		Inclusive: true,
+		Limit:     1,
+	})
+	if err != nil {
+		log.Error("getChannelMessage Error: ", err)
+		return slack.Message{}, err
+	}
+	for _, message := range history.Messages {
+		if message.Timestamp == timestamp {
+			return message, nil
+		}
+	}
+	// Replies are not in the history of the channel. Their thread starts with
+	// the parent message, so the reply is picked by its timestamp
+	messages, _, _, err := slackAPI.GetConversationReplies(&slack.GetConversationRepliesParameters{
+		ChannelID: channelID,
+		Timestamp: timestamp,
+		Oldest:    timestamp,
+		Latest:    timestamp,
+		Inclusive: true,
+	})
+	if err != nil {
+		log.Error("getChannelMessage Error: ", err)
+		return slack.Message{}, err
+	}
+	for _, message := range messages {
+		if message.Timestamp == timestamp {
+			return message, nil
+		}
+	}
+	return slack.Message{}, errors.New("message_not_found")
+}
+
+// ******************************************************************************
+// Name				: getSlackUserEmail
+// Description: Function to get the email of the profile of a slack user
+// ******************************************************************************
+func getSlackUserEmail(userID string) (string, error) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	user, err := slackAPI.GetUserInfo(userID)
+	if err != nil {
+		log.Error("getSlackUserEmail Error: ", err)
+		return "", err
+	}
+	return user.Profile.Email, nil
+}
+
+// ******************************************************************************
+// Name				: getSlackUserName
+// Description: Function to get the display name of a slack user, falling back
+// 							to the user ID when the user cannot be found
+// ******************************************************************************
+func getSlackUserName(userID string) string {
+	if name, ok := slackUserNameCache.Load(userID); ok {
+		return name.(string)
+	}
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	user, err := slackAPI.GetUserInfo(userID)
+	if err != nil {
+		log.Error("getSlackUserName Error: ", err)
+		return userID
+	}
+	name := user.Profile.DisplayName
+	if name == "" {
+		name = user.RealName
+	}
+	if name == "" {
+		name = user.Name
+	}
+	slackUserNameCache.Store(userID, name)
+	return name
+}
+
+// ******************************************************************************
+// Name				: archiveChannel
+// Description: Function to archive a slack channel
+// ******************************************************************************
+func archiveChannel(channelID string) error {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	err := slackAPI.ArchiveConversation(channelID)
+	if err != nil && err.Error() != "already_archived" {
+		log.Error("archiveChannel Error: ", err)
+		return err
+	}
+	return nil
+}
+
+// ******************************************************************************
+// Name				: pinMessage
+// Description: Function to pin a message to its channel
+// ******************************************************************************
+func pinMessage(message SlackMessageRef) error {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	err := slackAPI.AddPin(message.ChannelID, slack.NewRefToMessage(message.ChannelID, message.Timestamp))
+	if err != nil && err.Error() != "already_pinned" {
+		log.Error("pinMessage Error: ", err)
+		return err
+	}
+	return nil
+}
+
+// ******************************************************************************
+// Name				: addChannelBookmark
+// Description: Function to add a link bookmark to a slack channel. The slack
+// 							client has no support for bookmarks, so the Web API is
+// 							called directly
+// ******************************************************************************
+func addChannelBookmark(channelID string, title string, link string) error {
+	form := url.Values{
+		"channel_id": {channelID},
+		"title":      {title},
+		"type":       {"link"},
+		"link":       {link},
+	}
+	req, err := http.NewRequest("POST", slack.APIURL+"bookmarks.add", strings.NewReader(form.Encode()))
+	if err != nil {
+		log.Error("addChannelBookmark Error: ", err)
+		return err
+	}
+	req.Header.Add("Authorization", "Bearer "+os.Getenv("SLACK_ACCESS_TOKEN"))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Error("addChannelBookmark Error: ", err)
+		return err
+	}
+	defer resp.Body.Close()
+	var response slack.SlackResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		log.Error("addChannelBookmark parsing Error: ", err)
+		return err
+	}
+	if !response.Ok {
+		log.Error("addChannelBookmark Error: ", response.Error)
+		return errors.New(response.Error)
 	}
-	log.Info("No Channels configured for posting alerts")
+	return nil
 }
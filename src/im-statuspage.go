@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"os"
+	"strings"
 
+	statuspage "github.com/nagelflorian/statuspage-go"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -10,13 +14,13 @@ import (
 // Name				: createStatusPageIncident
 // Description: Function to create status page incident
 // ******************************************************************************
-func createStatusPageIncident(title string, description string, severity string, components []string) (*StatusPageIncident, error) {
-	pageID := constants.StatusPage.PageID
+func createStatusPageIncident(page StatusPagePage, title string, description string, severity string, components []string) (*StatusPageIncident, error) {
+	pageID := page.PageID
 	i := StatusPageIncident{
 		PageID:               pageID,
 		Name:                 title,
 		ImpactOverride:       "minor",
-		DeliverNotifications: constants.StatusPage.DeliverNotifications,
+		DeliverNotifications: page.DeliverNotifications,
 		ComponentIDs:         components,
 		Body:                 description,
 	}
@@ -32,6 +36,33 @@ func createStatusPageIncident(title string, description string, severity string,
 	return incident, err
 }
 
+// ******************************************************************************
+// Name				: createStatusPageIncidents
+// Description: Function to create the linked status page incidents on every
+// 							page the incident is routed to
+// ******************************************************************************
+func createStatusPageIncidents(title string, description string, severity string, routes []StatusPageRoute) ([]StatusPageIncidentRef, error) {
+	var refs []StatusPageIncidentRef
+	var failedPages []string
+	for _, route := range routes {
+		incident, err := createStatusPageIncident(route.Page, title, description, severity, route.ComponentIDs)
+		if err != nil {
+			failedPages = append(failedPages, route.Page.Name+" ("+err.Error()+")")
+			continue
+		}
+		refs = append(refs, StatusPageIncidentRef{
+			Page:       route.Page.Name,
+			PageID:     route.Page.PageID,
+			IncidentID: incident.ID,
+			Shortlink:  incident.Shortlink,
+		})
+	}
+	if len(failedPages) > 0 {
+		return refs, errors.New("failed to create StatusPage incident on " + strings.Join(failedPages, ", "))
+	}
+	return refs, nil
+}
+
 // ******************************************************************************
 // Name				: updateStatusPageIncident
 // Description: Function to update status page incident
@@ -52,3 +83,47 @@ func updateStatusPageIncident(processedMessage []string, incidentLink string) (*
 	}
 	return incident, err
 }
+
+// ******************************************************************************
+// Name				: getStatusPageIncident
+// Description: Function to fetch status page incident details
+// ******************************************************************************
+func getStatusPageIncident(incidentLink string) (*StatusPageIncident, error) {
+	incident, _, err := GetIncident(context.TODO(), incidentLink)
+	if err != nil {
+		log.Error("getStatusPageIncident Error: ", err)
+		return incident, err
+	}
+	return incident, err
+}
+
+// ******************************************************************************
+// Name				: listStatusPageComponents
+// Description: Function to list the components of a status page
+// ******************************************************************************
+func listStatusPageComponents(pageID string) ([]statuspage.Component, error) {
+	client := statuspage.NewClient(os.Getenv("STATUSPAGE_ACCESS_TOKEN"), nil)
+	components, err := client.Component.ListComponents(context.TODO(), pageID)
+	if err != nil {
+		log.Error("listStatusPageComponents Error: ", err)
+		return nil, err
+	}
+	return *components, err
+}
+
+// ******************************************************************************
+// Name				: updateStatusPageImpact
+// Description: Function to change the impact of a status page incident
+// ******************************************************************************
+func updateStatusPageImpact(incidentLink string, severity string) (*StatusPageIncident, error) {
+	i := StatusPageIncident{
+		ImpactOverride:       severity,
+		DeliverNotifications: constants.StatusPage.DeliverNotifications,
+	}
+	incident, _, err := UpdateIncident(context.TODO(), &i, incidentLink)
+	if err != nil {
+		log.Error("updateStatusPageImpact Error: ", err)
+		return incident, err
+	}
+	return incident, err
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+const defaultMergeLinkType = "Duplicate"
+
+// ******************************************************************************
+// Name				: getIncidentDescription
+// Description: Function to describe an incident in messages by its title,
+// 							channel and JIRA issue
+// ******************************************************************************
+func getIncidentDescription(record IncidentRecord) string {
+	description := "*" + record.Title + "*"
+	if record.ChannelID != "" {
+		description += " <#" + record.ChannelID + ">"
+	}
+	if record.JiraKey != "" {
+		description += " (<" + constants.JIRA.Endpoint + "/browse/" + record.JiraKey + "|" + record.JiraKey + ">)"
+	}
+	return description
+}
+
+// ******************************************************************************
+// Name				: closeDuplicateJiraIssue
+// Description: Function to link the JIRA issue of a duplicate incident to the
+// 							issue of the surviving incident and close it
+// ******************************************************************************
+func closeDuplicateJiraIssue(duplicate IncidentRecord, survivor IncidentRecord) []string {
+	var problems []string
+	if duplicate.JiraKey == "" {
+		return problems
+	}
+	if survivor.JiraKey != "" {
+		linkType := constants.JIRA.MergeLinkType
+		if linkType == "" {
+			linkType = defaultMergeLinkType
+		}
+		err := linkJiraIssues(duplicate.JiraKey, survivor.JiraKey, linkType)
+		if err != nil {
+			problems = append(problems, "JIRA issue "+duplicate.JiraKey+" could not be linked: "+err.Error())
+		}
+	}
+	err := closeJiraIssueAsDuplicate(duplicate.JiraKey)
+	if err != nil {
+		problems = append(problems, "JIRA issue "+duplicate.JiraKey+" could not be closed: "+err.Error())
+	}
+	return problems
+}
+
+// ******************************************************************************
+// Name				: resolveDuplicateStatusPageIncidents
+// Description: Function to resolve the StatusPage incidents of a duplicate
+// 							incident with a pointer to the surviving incident
+// ******************************************************************************
+func resolveDuplicateStatusPageIncidents(duplicate IncidentRecord, survivor IncidentRecord) []string {
+	var problems []string
+	body := "This incident is a duplicate of " + survivor.Title + " and is tracked there."
+	for _, statusPageIncident := range survivor.StatusPageIncidents {
+		if statusPageIncident.Shortlink != "" {
+			body = "This incident is a duplicate of " + survivor.Title + ", please follow " + statusPageIncident.Shortlink + " for updates."
+			break
+		}
+	}
+	for _, statusPageIncident := range duplicate.StatusPageIncidents {
+		if statusPageIncident.IncidentID == "" {
+			continue
+		}
+		link := getStatusPageLink(statusPageIncident.PageID, statusPageIncident.IncidentID)
+		_, err := updateStatusPageIncident([]string{"resolved", body}, link)
+		if err != nil {
+			problems = append(problems, "StatusPage incident "+statusPageIncident.IncidentID+" could not be resolved: "+err.Error())
+		}
+	}
+	return problems
+}
+
+// ******************************************************************************
+// Name				: inviteDuplicateMembers
+// Description: Function to invite the members of the channel of a duplicate
+// 							incident to the channel of the surviving incident
+// ******************************************************************************
+func inviteDuplicateMembers(duplicate IncidentRecord, survivor IncidentRecord) {
+	if duplicate.ChannelID == "" || survivor.ChannelID == "" {
+		return
+	}
+	members, err := getChannelMembers(duplicate.ChannelID)
+	if err != nil {
+		return
+	}
+	botUserID, _ := getSlackBotUserID()
+	var userIDs []string
+	for _, member := range members {
+		if member != botUserID {
+			userIDs = append(userIDs, member)
+		}
+	}
+	postInviteSummary(survivor.ChannelID, inviteUsersBestEffort(survivor.ChannelID, userIDs))
+}
+
+// ******************************************************************************
+// Name				: mergeCommandService
+// Description: Function to merge a duplicate incident into the incident of the
+// 							channel the command is used from. The duplicate is resolved
+// 							in JIRA, on StatusPage and in Falcon, and its responders are
+// 							sent to the surviving channel
+// ******************************************************************************
+func mergeCommandService(s slack.SlashCommand, arguments []string) {
+	survivor, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	duplicate, err := findIncidentByReference(arguments[1])
+	if err != nil {
+		msg := "ERROR!! No incident found for " + arguments[1] + ". Use the channel or the JIRA key of the duplicate incident."
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	if !canAccessIncident(duplicate, s.UserID) {
+		response := SlashResponse{"ephemeral", "ERROR!! The duplicate incident has a private channel. Only members of its channel can merge it."}
+		slackCommandResponse(response, s)
+		return
+	}
+	if duplicate.ID == survivor.ID {
+		response := SlashResponse{"ephemeral", "ERROR!! An incident cannot be merged into itself. Use the command from the channel of the incident to keep."}
+		slackCommandResponse(response, s)
+		return
+	}
+	if survivor.MergedInto != 0 {
+		response := SlashResponse{"ephemeral", "ERROR!! This incident was merged into another incident. Use the command from the channel of that incident."}
+		slackCommandResponse(response, s)
+		return
+	}
+
+	// The duplicate is claimed first, so that it cannot be merged twice at
+	// the same time, without holding a lock during the calls to JIRA and
+	// StatusPage
+	claimed := false
+	_, err = incidentStore.Update(duplicate.ID, func(record *IncidentRecord) {
+		if record.MergedInto == 0 {
+			record.MergedInto = survivor.ID
+			claimed = true
+		}
+	})
+	if err != nil {
+		msg := "ERROR!! Error updating the incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	if !claimed {
+		response := SlashResponse{"ephemeral", "ERROR!! " + arguments[1] + " was already merged into another incident."}
+		slackCommandResponse(response, s)
+		return
+	}
+
+	var problems []string
+	problems = append(problems, closeDuplicateJiraIssue(duplicate, survivor)...)
+	problems = append(problems, resolveDuplicateStatusPageIncidents(duplicate, survivor)...)
+	duplicate, err = updateIncident(duplicate.ID, func(record *IncidentRecord) {
+		record.Status = "resolved"
+		record.JiraPhase = "resolved"
+		if record.ResolvedAt.IsZero() {
+			record.ResolvedAt = time.Now()
+		}
+	})
+	if err != nil {
+		msg := "ERROR!! Error updating the incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	if duplicate.LastDigestStatus != "resolved" {
+		postStakeholderDigest(duplicate, "resolved", "Duplicate of "+getIncidentDescription(survivor))
+	}
+
+	if duplicate.ChannelID != "" {
+		postMessageToChannel(duplicate.ChannelID, ":arrow_right: <@"+s.UserID+"> merged this incident into "+getIncidentDescription(survivor)+
+			" as a duplicate. Please continue in <#"+survivor.ChannelID+">.")
+	}
+	inviteDuplicateMembers(duplicate, survivor)
+	for _, problem := range problems {
+		log.Warn("mergeCommandService: ", problem)
+	}
+	responseText := "<@" + s.UserID + "> merged " + getIncidentDescription(duplicate) + " into this incident"
+	if len(problems) > 0 {
+		responseText += "\nThe following could not be done, please take care of it manually:\n• " + strings.Join(problems, "\n• ")
+	}
+	response := SlashResponse{"in_channel", responseText}
+	slackCommandResponse(response, s)
+}
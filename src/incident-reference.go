@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// Prefix of the argument naming the incident a command is used for
+const incidentArgumentPrefix = "inc="
+
+var errIncidentAccessDenied = errors.New("IncidentAccessDenied")
+
+var jiraKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-[0-9]+$`)
+
+// Escaped channel mentions look like <#C0123ABCD|name> or <#C0123ABCD>
+var slackChannelMention = regexp.MustCompile(`^<#([A-Z0-9]+)(\|[^>]*)?>$`)
+
+var slackChannelID = regexp.MustCompile(`^[CG][A-Z0-9]{6,}$`)
+
+var incidentNumber = regexp.MustCompile(`^#?[0-9]+$`)
+
+// ******************************************************************************
+// Name				: findIncidentByReference
+// Description: Function to find an incident by its number, the key of its JIRA
+// 							issue, the ID of one of its StatusPage incidents or by its
+// 							slack channel, given as mention, ID or #name
+// ******************************************************************************
+func findIncidentByReference(reference string) (IncidentRecord, error) {
+	reference = strings.TrimSpace(reference)
+	if match := slackChannelMention.FindStringSubmatch(reference); match != nil {
+		return incidentStore.FindByChannel(match[1])
+	}
+	if incidentNumber.MatchString(reference) {
+		id, err := strconv.Atoi(strings.TrimPrefix(reference, "#"))
+		if err != nil {
+			return IncidentRecord{}, errIncidentNotFound
+		}
+		return incidentStore.FindByID(id)
+	}
+	// JIRA keys are upper case while channel names are lower case
+	if jiraKeyPattern.MatchString(reference) {
+		return incidentStore.Find(func(record *IncidentRecord) bool {
+			return strings.EqualFold(record.JiraKey, reference)
+		})
+	}
+	if slackChannelID.MatchString(reference) {
+		return incidentStore.FindByChannel(reference)
+	}
+	record, err := findIncidentByStatusPageID(reference)
+	if err == nil {
+		return record, nil
+	}
+	channelID, err := getChannelIDByName(strings.TrimPrefix(reference, "#"))
+	if err != nil {
+		return IncidentRecord{}, errIncidentNotFound
+	}
+	return incidentStore.FindByChannel(channelID)
+}
+
+// ******************************************************************************
+// Name				: findIncidentByStatusPageID
+// Description: Function to find the incident one of whose StatusPage incidents
+// 							has the given ID
+// ******************************************************************************
+func findIncidentByStatusPageID(incidentID string) (IncidentRecord, error) {
+	return incidentStore.Find(func(record *IncidentRecord) bool {
+		for _, ref := range record.StatusPageIncidents {
+			if ref.IncidentID == incidentID {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ******************************************************************************
+// Name				: splitIncidentArgument
+// Description: Function to take the unquoted `inc=<reference>` word naming the
+// 							incident out of the text of a command. Quoted arguments are
+// 							left as they are, so comments may start with inc=
+// ******************************************************************************
+func splitIncidentArgument(text string) (string, string) {
+	reference := ""
+	segments := strings.Split(text, "\"")
+	// Every other segment is outside of double quotes
+	for i := 0; i < len(segments); i += 2 {
+		words := strings.Fields(segments[i])
+		var rest []string
+		for _, word := range words {
+			if strings.HasPrefix(strings.ToLower(word), incidentArgumentPrefix) {
+				reference = word[len(incidentArgumentPrefix):]
+				continue
+			}
+			rest = append(rest, word)
+		}
+		if len(rest) != len(words) {
+			segments[i] = " " + strings.Join(rest, " ") + " "
+		}
+	}
+	return strings.Join(segments, "\""), reference
+}
+
+// ******************************************************************************
+// Name				: canAccessIncident
+// Description: Function to check if a slack user may use commands for an
+// 							incident from outside its channel. Incidents with a private
+// 							channel are only open to the members of the channel
+// ******************************************************************************
+func canAccessIncident(record IncidentRecord, userID string) bool {
+	if !record.PrivateChannel {
+		return true
+	}
+	return isChannelMember(record.ChannelID, userID)
+}
+
+// ******************************************************************************
+// Name				: isIncidentResponder
+// Description: Function to check if a slack user responds to an incident, i.e.
+// 							is its commander or a member of its channel
+// ******************************************************************************
+func isIncidentResponder(record IncidentRecord, userID string) bool {
+	if record.CommanderID == userID {
+		return true
+	}
+	return isChannelMember(record.ChannelID, userID)
+}
+
+// ******************************************************************************
+// Name				: isChannelMember
+// Description: Function to check if a slack user is a member of a channel
+// ******************************************************************************
+func isChannelMember(channelID string, userID string) bool {
+	members, err := getChannelMembers(channelID)
+	if err != nil {
+		return false
+	}
+	for _, member := range members {
+		if member == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ******************************************************************************
+// Name				: resolveCommandIncident
+// Description: Function to point a command at the incident named with
+// 							`inc=<reference>`, so it works the same as when used from the
+// 							incident channel. Without a reference the current channel is
+// 							used
+// ******************************************************************************
+func resolveCommandIncident(s *slack.SlashCommand, reference string) error {
+	if reference == "" {
+		return nil
+	}
+	// JIRA keys given with inc= are unambiguous, so they may be lower case
+	if jiraKeyPattern.MatchString(strings.ToUpper(reference)) {
+		reference = strings.ToUpper(reference)
+	}
+	record, err := findIncidentByReference(reference)
+	if err != nil || record.ChannelID == "" {
+		return errIncidentNotFound
+	}
+	if record.ChannelID != s.ChannelID && !canAccessIncident(record, s.UserID) {
+		return errIncidentAccessDenied
+	}
+	s.ChannelID = record.ChannelID
+	return nil
+}
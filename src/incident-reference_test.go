@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// Splits the text left for the command the same way as slackController
+func commandArguments(text string) []string {
+	var arguments []string
+	for _, j := range strings.Split(text, "\"") {
+		j = strings.Trim(j, "\" ")
+		if j != "" {
+			arguments = append(arguments, j)
+		}
+	}
+	return arguments
+}
+
+func TestSplitIncidentArgument(t *testing.T) {
+	tests := []struct {
+		text      string
+		arguments []string
+		reference string
+	}{
+		{`"comment" "text"`, []string{"comment", "text"}, ""},
+		{`inc=42 "comment" "text"`, []string{"comment", "text"}, "42"},
+		{`"comment" "text" inc=INC-7`, []string{"comment", "text"}, "INC-7"},
+		{`"comment" INC=#42 "text"`, []string{"comment", "text"}, "#42"},
+		{`"comment" "inc=42 is related"`, []string{"comment", "inc=42 is related"}, ""},
+		{`"comment" "inc=42"`, []string{"comment", "inc=42"}, ""},
+		{`inc=<#C0123ABCD|incident-42> "comment" "inc=7"`, []string{"comment", "inc=7"}, "<#C0123ABCD|incident-42>"},
+		{`inc=42`, nil, "42"},
+	}
+	for _, test := range tests {
+		text, reference := splitIncidentArgument(test.text)
+		arguments := commandArguments(text)
+		if !reflect.DeepEqual(arguments, test.arguments) || reference != test.reference {
+			t.Errorf("splitIncidentArgument(%q) = %q, %q, want %q, %q", test.text, arguments, reference, test.arguments, test.reference)
+		}
+	}
+}
+
+// Replaces the incident store for the duration of a test
+func useIncidentStore(t *testing.T, records ...*IncidentRecord) {
+	saved := incidentStore
+	incidentStore = &IncidentStore{NextID: len(records) + 1, Incidents: records}
+	t.Cleanup(func() { incidentStore = saved })
+}
+
+func TestFindIncidentByReference(t *testing.T) {
+	useIncidentStore(t,
+		&IncidentRecord{ID: 1, ChannelID: "C0123ABCD", JiraKey: "INC-7"},
+		&IncidentRecord{ID: 2, ChannelID: "G0456EFGH", JiraKey: "INC-8", StatusPageIncidents: []StatusPageIncidentRef{{IncidentID: "p31zjtct2jer"}}},
+	)
+	tests := []struct {
+		reference string
+		id        int
+	}{
+		{"1", 1},
+		{"#2", 2},
+		{"INC-7", 1},
+		{"<#C0123ABCD|incident-1>", 1},
+		{"<#G0456EFGH>", 2},
+		{"C0123ABCD", 1},
+		{" G0456EFGH ", 2},
+		{"p31zjtct2jer", 2},
+	}
+	for _, test := range tests {
+		record, err := findIncidentByReference(test.reference)
+		if err != nil || record.ID != test.id {
+			t.Errorf("findIncidentByReference(%q) = %d, %v, want %d", test.reference, record.ID, err, test.id)
+		}
+	}
+	for _, reference := range []string{"3", "#0", "INC-9", "<#C9999ZZZZ>", "C9999ZZZZ"} {
+		_, err := findIncidentByReference(reference)
+		if err != errIncidentNotFound {
+			t.Errorf("findIncidentByReference(%q) error = %v, want %v", reference, err, errIncidentNotFound)
+		}
+	}
+}
+
+func TestResolveCommandIncident(t *testing.T) {
+	useIncidentStore(t,
+		&IncidentRecord{ID: 1, ChannelID: "C0123ABCD", JiraKey: "INC-7"},
+		&IncidentRecord{ID: 2, ChannelID: "G0456EFGH", JiraKey: "INC-8", PrivateChannel: true},
+	)
+	s := slack.SlashCommand{ChannelID: "C0000HOME", UserID: "U0123ABCD"}
+	if err := resolveCommandIncident(&s, ""); err != nil || s.ChannelID != "C0000HOME" {
+		t.Errorf("resolveCommandIncident without reference = %q, %v", s.ChannelID, err)
+	}
+	if err := resolveCommandIncident(&s, "inc-7"); err != nil || s.ChannelID != "C0123ABCD" {
+		t.Errorf("resolveCommandIncident(inc-7) = %q, %v, want C0123ABCD", s.ChannelID, err)
+	}
+	// Members of a private channel use commands from the channel itself
+	s.ChannelID = "G0456EFGH"
+	if err := resolveCommandIncident(&s, "#2"); err != nil || s.ChannelID != "G0456EFGH" {
+		t.Errorf("resolveCommandIncident(#2) from its channel = %q, %v", s.ChannelID, err)
+	}
+	if err := resolveCommandIncident(&s, "#3"); err != errIncidentNotFound {
+		t.Errorf("resolveCommandIncident(#3) error = %v, want %v", err, errIncidentNotFound)
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// StatusPageIncidentRef links an incident to a StatusPage incident on one of
+// the configured pages
+type StatusPageIncidentRef struct {
+	Page       string `json:"page"`
+	PageID     string `json:"page_id"`
+	IncidentID string `json:"incident_id"`
+	Shortlink  string `json:"shortlink,omitempty"`
+}
+
+// SlackMessageRef identifies a message Falcon posted about an incident
+type SlackMessageRef struct {
+	ChannelID string `json:"channel_id"`
+	Timestamp string `json:"timestamp"`
+}
+
+// IncidentRecord is the state Falcon keeps about an incident
+type IncidentRecord struct {
+	ID                    int                       `json:"id"`
+	Title                 string                    `json:"title"`
+	Severity              string                    `json:"severity,omitempty"`
+	Status                string                    `json:"status,omitempty"`
+	AcknowledgedBy        string                    `json:"acknowledged_by,omitempty"`
+	CommanderID           string                    `json:"commander_id,omitempty"`
+	Services              []string                  `json:"services,omitempty"`
+	ChannelID             string                    `json:"channel_id,omitempty"`
+	PrivateChannel        bool                      `json:"private_channel,omitempty"`
+	JiraKey               string                    `json:"jira_key,omitempty"`
+	PagerDutyURL          string                    `json:"pagerduty_url,omitempty"`
+	PDServiceID           string                    `json:"pd_service_id,omitempty"`
+	SPComponentIDs        []string                  `json:"sp_component_ids,omitempty"`
+	StatusPageIncidents   []StatusPageIncidentRef   `json:"statuspage_incidents,omitempty"`
+	Visibility            string                    `json:"visibility,omitempty"`
+	PendingUpdates        []PendingStatusPageUpdate `json:"pending_updates,omitempty"`
+	LastPendingUpdateID   int                       `json:"last_pending_update_id,omitempty"`
+	AlertMessages         []SlackMessageRef         `json:"alert_messages,omitempty"`
+	LastDigestStatus      string                    `json:"last_digest_status,omitempty"`
+	CardMessage           *SlackMessageRef          `json:"card_message,omitempty"`
+	Bookmarks             []string                  `json:"bookmarks,omitempty"`
+	CreatedAt             time.Time                 `json:"created_at"`
+	LastUpdateAt          time.Time                 `json:"last_update_at"`
+	LastReminderAt        time.Time                 `json:"last_reminder_at"`
+	RemindersSnoozedUntil time.Time                 `json:"reminders_snoozed_until"`
+	RemindersDisabled     bool                      `json:"reminders_disabled,omitempty"`
+	ResolvedAt            time.Time                 `json:"resolved_at"`
+	KeepOpenUntil         time.Time                 `json:"keep_open_until"`
+	ArchiveWarningSent    bool                      `json:"archive_warning_sent,omitempty"`
+	ArchiveAttempts       int                       `json:"archive_attempts,omitempty"`
+	NextArchiveAttemptAt  time.Time                 `json:"next_archive_attempt_at"`
+	ArchivedAt            time.Time                 `json:"archived_at"`
+	JiraPhase             string                    `json:"jira_phase,omitempty"`
+	MirrorMode            string                    `json:"mirror_mode,omitempty"`
+	MirroredComments      map[string]string         `json:"mirrored_comments,omitempty"`
+	TranscriptURL         string                    `json:"transcript_url,omitempty"`
+	TranscriptAt          time.Time                 `json:"transcript_at"`
+	MergedInto            int                       `json:"merged_into,omitempty"`
+	RelatedJiraKeys       []string                  `json:"related_jira_keys,omitempty"`
+	ActionItems           []ActionItem              `json:"action_items,omitempty"`
+}
+
+// IncidentStore is the file backed list of incidents handled by Falcon
+type IncidentStore struct {
+	sync.Mutex
+	NextID             int               `json:"next_id"`
+	Incidents          []*IncidentRecord `json:"incidents"`
+	LastActionDigestAt time.Time         `json:"last_action_digest_at"`
+	HomeViewers        []string          `json:"home_viewers,omitempty"`
+}
+
+var incidentStore = &IncidentStore{NextID: 1}
+
+var errIncidentNotFound = errors.New("IncidentNotFound")
+
+// ******************************************************************************
+// Name				: incidentStoreInitializer
+// Description: Function to load the incident store from disk
+// ******************************************************************************
+func incidentStoreInitializer() {
+	data, err := ioutil.ReadFile(constants.IncidentStore.Path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error("incidentStoreInitializer Error: ", err)
+		}
+		return
+	}
+	incidentStore.Lock()
+	defer incidentStore.Unlock()
+	err = json.Unmarshal(data, incidentStore)
+	if err != nil {
+		log.Error("incidentStoreInitializer Parsing Error: ", err)
+	}
+	if incidentStore.NextID < 1 {
+		incidentStore.NextID = 1
+	}
+}
+
+// ******************************************************************************
+// Name				: persist
+// Description: Function to write the incident store to disk. The caller must
+// 							hold the store lock
+// ******************************************************************************
+func (store *IncidentStore) persist() error {
+	data, err := json.MarshalIndent(store, "", "\t")
+	if err != nil {
+		log.Error("IncidentStore persist Error: ", err)
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(constants.IncidentStore.Path), os.ModePerm)
+	if err != nil {
+		log.Error("IncidentStore persist Error: ", err)
+		return err
+	}
+	tmpPath := constants.IncidentStore.Path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, data, 0644)
+	if err != nil {
+		log.Error("IncidentStore persist Error: ", err)
+		return err
+	}
+	err = os.Rename(tmpPath, constants.IncidentStore.Path)
+	if err != nil {
+		log.Error("IncidentStore persist Error: ", err)
+	}
+	return err
+}
+
+// ******************************************************************************
+// Name				: Add
+// Description: Function to add a new incident to the store and assign its ID
+// ******************************************************************************
+func (store *IncidentStore) Add(record IncidentRecord) (IncidentRecord, error) {
+	store.Lock()
+	defer store.Unlock()
+	record.ID = store.NextID
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	store.NextID++
+	store.Incidents = append(store.Incidents, &record)
+	return record, store.persist()
+}
+
+// ******************************************************************************
+// Name				: Update
+// Description: Function to modify an incident in place and persist the change
+// ******************************************************************************
+func (store *IncidentStore) Update(id int, update func(record *IncidentRecord)) (IncidentRecord, error) {
+	store.Lock()
+	defer store.Unlock()
+	for _, record := range store.Incidents {
+		if record.ID == id {
+			update(record)
+			return *record, store.persist()
+		}
+	}
+	return IncidentRecord{}, errIncidentNotFound
+}
+
+// ******************************************************************************
+// Name				: Find
+// Description: Function to return a copy of the first incident matching the
+// 							given condition
+// ******************************************************************************
+func (store *IncidentStore) Find(match func(record *IncidentRecord) bool) (IncidentRecord, error) {
+	store.Lock()
+	defer store.Unlock()
+	for _, record := range store.Incidents {
+		if match(record) {
+			return *record, nil
+		}
+	}
+	return IncidentRecord{}, errIncidentNotFound
+}
+
+// ******************************************************************************
+// Name				: List
+// Description: Function to return copies of all incidents matching the given
+// 							condition
+// ******************************************************************************
+func (store *IncidentStore) List(match func(record *IncidentRecord) bool) []IncidentRecord {
+	store.Lock()
+	defer store.Unlock()
+	var records []IncidentRecord
+	for _, record := range store.Incidents {
+		if match(record) {
+			records = append(records, *record)
+		}
+	}
+	return records
+}
+
+// ******************************************************************************
+// Name				: FindByChannel
+// Description: Function to get the incident handled in a Slack channel
+// ******************************************************************************
+func (store *IncidentStore) FindByChannel(channelID string) (IncidentRecord, error) {
+	return store.Find(func(record *IncidentRecord) bool {
+		return channelID != "" && record.ChannelID == channelID
+	})
+}
+
+// ******************************************************************************
+// Name				: FindByID
+// Description: Function to get an incident by its ID
+// ******************************************************************************
+func (store *IncidentStore) FindByID(id int) (IncidentRecord, error) {
+	return store.Find(func(record *IncidentRecord) bool {
+		return record.ID == id
+	})
+}
+
+// ******************************************************************************
+// Name				: GetLastActionDigestAt
+// Description: Function to get when the overdue action items were last reported
+// ******************************************************************************
+func (store *IncidentStore) GetLastActionDigestAt() time.Time {
+	store.Lock()
+	defer store.Unlock()
+	return store.LastActionDigestAt
+}
+
+// ******************************************************************************
+// Name				: SetLastActionDigestAt
+// Description: Function to remember when the overdue action items were reported
+// ******************************************************************************
+func (store *IncidentStore) SetLastActionDigestAt(at time.Time) error {
+	store.Lock()
+	defer store.Unlock()
+	store.LastActionDigestAt = at
+	return store.persist()
+}
+
+// ******************************************************************************
+// Name				: GetHomeViewers
+// Description: Function to get the users who opened the App Home tab of Falcon
+// ******************************************************************************
+func (store *IncidentStore) GetHomeViewers() []string {
+	store.Lock()
+	defer store.Unlock()
+	return append([]string(nil), store.HomeViewers...)
+}
+
+// ******************************************************************************
+// Name				: AddHomeViewer
+// Description: Function to remember a user who opened the App Home tab, so that
+// 							their tab is still refreshed after a restart
+// ******************************************************************************
+func (store *IncidentStore) AddHomeViewer(userID string) error {
+	store.Lock()
+	defer store.Unlock()
+	for _, j := range store.HomeViewers {
+		if j == userID {
+			return nil
+		}
+	}
+	store.HomeViewers = append(store.HomeViewers, userID)
+	return store.persist()
+}
+
+// ******************************************************************************
+// Name				: updateIncidentForChannel
+// Description: Function to change the state of the incident of a Slack channel
+// 							and refresh everything that shows the incident state
+// ******************************************************************************
+func updateIncidentForChannel(channelID string, update func(record *IncidentRecord)) error {
+	record, err := incidentStore.FindByChannel(channelID)
+	if err != nil {
+		return err
+	}
+	_, err = updateIncident(record.ID, update)
+	return err
+}
+
+// ******************************************************************************
+// Name				: updateIncident
+// Description: Function to change the state of an incident and refresh
+// 							everything that shows the incident state
+// ******************************************************************************
+func updateIncident(id int, update func(record *IncidentRecord)) (IncidentRecord, error) {
+	record, err := incidentStore.Update(id, update)
+	if err != nil {
+		log.Error("updateIncident Error: ", err)
+		return record, err
+	}
+	incidentStateChanged(record)
+	return record, nil
+}
+
+// ******************************************************************************
+// Name				: trackIncidentStatus
+// Description: Function to remember the update posted by a comment and the
+// 							status it set. The "current" status leaves the status
+// 							unchanged. Resolving the incident starts the countdown to
+// 							archiving its channel and is announced to stakeholders
+// ******************************************************************************
+func trackIncidentStatus(channelID string, status string) {
+	record, err := incidentStore.FindByChannel(channelID)
+	if err != nil {
+		return
+	}
+	record, err = updateIncident(record.ID, func(record *IncidentRecord) {
+		record.LastUpdateAt = time.Now()
+		if status == "" || status == "current" {
+			return
+		}
+		record.Status = status
+		if status != "resolved" {
+			record.ResolvedAt = time.Time{}
+			record.ArchiveWarningSent = false
+			record.ArchiveAttempts = 0
+			record.NextArchiveAttemptAt = time.Time{}
+			record.TranscriptURL = ""
+			record.TranscriptAt = time.Time{}
+		} else if record.ResolvedAt.IsZero() {
+			record.ResolvedAt = time.Now()
+		}
+	})
+	// Resolutions that were not published on StatusPage still reach stakeholders
+	if err != nil || record.Status != "resolved" {
+		return
+	}
+	if record.LastDigestStatus != "resolved" {
+		postStakeholderDigest(record, "resolved", "")
+		record, err = incidentStore.FindByID(record.ID)
+		if err != nil {
+			return
+		}
+	}
+	// Incidents without notification channels get their transcript attached too
+	getResolutionTranscriptLink(record)
+}
+
+// ******************************************************************************
+// Name				: getIncidentIDForChannel
+// Description: Function to get the incident of a Slack channel, registering the
+// 							channel in the store if it was created before the store
+// 							existed
+// ******************************************************************************
+func getIncidentIDForChannel(channelID string, title string, jiraURL string) int {
+	record, err := incidentStore.FindByChannel(channelID)
+	if err == nil {
+		return record.ID
+	}
+	record, err = incidentStore.Add(IncidentRecord{
+		Title:     title,
+		ChannelID: channelID,
+		JiraKey:   getJiraKeyFromURL(jiraURL),
+	})
+	if err != nil {
+		log.Error("getIncidentIDForChannel Error: ", err)
+	}
+	return record.ID
+}
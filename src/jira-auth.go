@@ -0,0 +1,269 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// Ways of authenticating with JIRA, selected with jira.auth
+const (
+	jiraAuthBasic    = "basic"
+	jiraAuthAPIToken = "api_token"
+	jiraAuthPAT      = "pat"
+	jiraAuthOAuth1   = "oauth1"
+)
+
+// BearerAuthTransport authenticates requests with a personal access token of
+// JIRA Data Center
+type BearerAuthTransport struct {
+	Token     string
+	Transport http.RoundTripper
+}
+
+// ******************************************************************************
+// Name				: RoundTrip
+// Description: Function to send a request with the bearer token
+// ******************************************************************************
+func (t *BearerAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req2 := req.Clone(req.Context())
+	req2.Header.Set("Authorization", "Bearer "+t.Token)
+	return getTransport(t.Transport).RoundTrip(req2)
+}
+
+// OAuth1Transport signs requests with OAuth 1.0a and RSA-SHA1, as JIRA
+// application links expect
+type OAuth1Transport struct {
+	ConsumerKey string
+	AccessToken string
+	PrivateKey  *rsa.PrivateKey
+	Transport   http.RoundTripper
+}
+
+// ******************************************************************************
+// Name				: RoundTrip
+// Description: Function to send a request with the OAuth 1.0a authorization
+// 							header
+// ******************************************************************************
+func (t *OAuth1Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]string{
+		"oauth_consumer_key":     t.ConsumerKey,
+		"oauth_token":            t.AccessToken,
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_nonce":            hex.EncodeToString(nonce),
+		"oauth_version":          "1.0",
+	}
+	signature, err := t.sign(req, params)
+	if err != nil {
+		return nil, err
+	}
+	params["oauth_signature"] = signature
+
+	var header []string
+	for key, value := range params {
+		header = append(header, oauthEscape(key)+"=\""+oauthEscape(value)+"\"")
+	}
+	sort.Strings(header)
+	req2 := req.Clone(req.Context())
+	req2.Header.Set("Authorization", "OAuth "+strings.Join(header, ", "))
+	return getTransport(t.Transport).RoundTrip(req2)
+}
+
+// ******************************************************************************
+// Name				: getSignatureBaseString
+// Description: Function to build the OAuth 1.0a signature base string of a
+// 							request from its method, URL, query parameters and OAuth
+// 							parameters
+// ******************************************************************************
+func getSignatureBaseString(req *http.Request, oauthParams map[string]string) string {
+	var pairs [][2]string
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{oauthEscape(key), oauthEscape(value)})
+		}
+	}
+	for key, value := range oauthParams {
+		pairs = append(pairs, [2]string{oauthEscape(key), oauthEscape(value)})
+	}
+	// Parameters are sorted by name, and by value for repeated names
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	var params []string
+	for _, pair := range pairs {
+		params = append(params, pair[0]+"="+pair[1])
+	}
+
+	baseURL := url.URL{
+		Scheme: strings.ToLower(req.URL.Scheme),
+		Host:   strings.ToLower(req.URL.Host),
+		Path:   req.URL.Path,
+	}
+	if (baseURL.Scheme == "https" && strings.HasSuffix(baseURL.Host, ":443")) || (baseURL.Scheme == "http" && strings.HasSuffix(baseURL.Host, ":80")) {
+		baseURL.Host = baseURL.Host[:strings.LastIndex(baseURL.Host, ":")]
+	}
+	return strings.ToUpper(req.Method) + "&" + oauthEscape(baseURL.String()) + "&" + oauthEscape(strings.Join(params, "&"))
+}
+
+// ******************************************************************************
+// Name				: sign
+// Description: Function to compute the RSA-SHA1 signature of the signature
+// 							base string of a request
+// ******************************************************************************
+func (t *OAuth1Transport) sign(req *http.Request, oauthParams map[string]string) (string, error) {
+	hash := sha1.Sum([]byte(getSignatureBaseString(req, oauthParams)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.PrivateKey, crypto.SHA1, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// ******************************************************************************
+// Name				: oauthEscape
+// Description: Function to percent encode a value as OAuth 1.0a requires: every
+// 							character but letters, digits and -._~ is encoded
+// ******************************************************************************
+func oauthEscape(value string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(value) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') || b == '-' || b == '.' || b == '_' || b == '~' {
+			escaped.WriteByte(b)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+// ******************************************************************************
+// Name				: getTransport
+// Description: Function to get the transport to send requests with, the default
+// 							one unless given
+// ******************************************************************************
+func getTransport(transport http.RoundTripper) http.RoundTripper {
+	if transport != nil {
+		return transport
+	}
+	return http.DefaultTransport
+}
+
+// ******************************************************************************
+// Name				: parseRSAPrivateKey
+// Description: Function to parse a PEM encoded RSA private key in PKCS #1 or
+// 							PKCS #8 form
+// ******************************************************************************
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// ******************************************************************************
+// Name				: getOAuth1PrivateKey
+// Description: Function to load the private key of the JIRA application link
+// 							from JIRA_OAUTH_PRIVATE_KEY, or from the file in
+// 							JIRA_OAUTH_PRIVATE_KEY_PATH
+// ******************************************************************************
+func getOAuth1PrivateKey() (*rsa.PrivateKey, error) {
+	data := []byte(os.Getenv("JIRA_OAUTH_PRIVATE_KEY"))
+	if len(data) == 0 {
+		path := os.Getenv("JIRA_OAUTH_PRIVATE_KEY_PATH")
+		if path == "" {
+			return nil, errors.New("JIRA_OAUTH_PRIVATE_KEY or JIRA_OAUTH_PRIVATE_KEY_PATH must be set")
+		}
+		var err error
+		data, err = ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseRSAPrivateKey(data)
+}
+
+// ******************************************************************************
+// Name				: getJiraHTTPClient
+// Description: Function to get the HTTP client authenticating with JIRA as
+// 							configured in jira.auth
+// ******************************************************************************
+func getJiraHTTPClient(auth string) (*http.Client, error) {
+	switch auth {
+	case "", jiraAuthBasic:
+		tp := jira.BasicAuthTransport{
+			Username: os.Getenv("JIRA_USERNAME"),
+			Password: os.Getenv("JIRA_PASSWORD"),
+		}
+		return tp.Client(), nil
+	case jiraAuthAPIToken:
+		token := os.Getenv("JIRA_API_TOKEN")
+		if token == "" {
+			return nil, errors.New("JIRA_API_TOKEN must be set")
+		}
+		// JIRA Cloud takes the email of the account and an API token as basic auth
+		tp := jira.BasicAuthTransport{
+			Username: os.Getenv("JIRA_USERNAME"),
+			Password: token,
+		}
+		return tp.Client(), nil
+	case jiraAuthPAT:
+		token := os.Getenv("JIRA_PERSONAL_ACCESS_TOKEN")
+		if token == "" {
+			return nil, errors.New("JIRA_PERSONAL_ACCESS_TOKEN must be set")
+		}
+		return &http.Client{Transport: &BearerAuthTransport{Token: token}}, nil
+	case jiraAuthOAuth1:
+		key, err := getOAuth1PrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		transport := &OAuth1Transport{
+			ConsumerKey: os.Getenv("JIRA_OAUTH_CONSUMER_KEY"),
+			AccessToken: os.Getenv("JIRA_OAUTH_ACCESS_TOKEN"),
+			PrivateKey:  key,
+		}
+		if transport.ConsumerKey == "" || transport.AccessToken == "" {
+			return nil, errors.New("JIRA_OAUTH_CONSUMER_KEY and JIRA_OAUTH_ACCESS_TOKEN must be set")
+		}
+		return &http.Client{Transport: transport}, nil
+	}
+	return nil, errors.New("unknown jira.auth " + auth + ", use one of basic, api_token, pat or oauth1")
+}
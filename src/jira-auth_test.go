@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"testing"
+)
+
+// Parameters of the example in section 3.4.1 of RFC 5849
+var rfc5849Params = map[string]string{
+	"oauth_consumer_key":     "9djdj82h48djs9d2",
+	"oauth_token":            "kkk9d7dh3k39sjv7",
+	"oauth_signature_method": "HMAC-SHA1",
+	"oauth_timestamp":        "137131201",
+	"oauth_nonce":            "7d8f3e4a",
+}
+
+// The body parameters of the RFC example are sent in the query, as JIRA
+// requests have JSON bodies
+const rfc5849URL = "http://EXAMPLE.COM:80/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b&c2=&a3=2%20q"
+
+const rfc5849BaseString = "POST&http%3A%2F%2Fexample.com%2Frequest&a2%3Dr%2520b%26a3%3D2%2520q%26a3%3Da%26b5%3D%253D%25253D%26c%2540%3D%26c2%3D%26oauth_consumer_key%3D9djdj82h48djs9d2%26oauth_nonce%3D7d8f3e4a%26oauth_signature_method%3DHMAC-SHA1%26oauth_timestamp%3D137131201%26oauth_token%3Dkkk9d7dh3k39sjv7"
+
+func TestOAuthEscape(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		"abcXYZ019-._~":      "abcXYZ019-._~",
+		"Ladies + Gentlemen": "Ladies%20%2B%20Gentlemen",
+		"An encoded string!": "An%20encoded%20string%21",
+		"Dogs, Cats & Mice":  "Dogs%2C%20Cats%20%26%20Mice",
+		"a=b/c?d":            "a%3Db%2Fc%3Fd",
+		"%3D":                "%253D",
+		"☃":                  "%E2%98%83",
+	}
+	for value, expected := range tests {
+		if escaped := oauthEscape(value); escaped != expected {
+			t.Errorf("oauthEscape(%q) = %q, expected %q", value, escaped, expected)
+		}
+	}
+}
+
+func TestGetSignatureBaseString(t *testing.T) {
+	req, err := http.NewRequest("post", rfc5849URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base := getSignatureBaseString(req, rfc5849Params); base != rfc5849BaseString {
+		t.Errorf("getSignatureBaseString() = %q, expected %q", base, rfc5849BaseString)
+	}
+}
+
+func TestGetSignatureBaseStringKeepsPort(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://jira.example.com:8443/rest/api/2/myself", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "GET&https%3A%2F%2Fjira.example.com%3A8443%2Frest%2Fapi%2F2%2Fmyself&oauth_token%3Dt"
+	if base := getSignatureBaseString(req, map[string]string{"oauth_token": "t"}); base != expected {
+		t.Errorf("getSignatureBaseString() = %q, expected %q", base, expected)
+	}
+}
+
+func TestSign(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", rfc5849URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &OAuth1Transport{PrivateKey: key}
+	signature, err := transport.sign(req, rfc5849Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha1.Sum([]byte(rfc5849BaseString))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, hash[:], decoded); err != nil {
+		t.Errorf("signature does not match the signature base string: %v", err)
+	}
+}
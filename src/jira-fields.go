@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	log "github.com/sirupsen/logrus"
+	"github.com/trivago/tgo/tcontainer"
+)
+
+// JiraIssueData holds what the fields of the JIRA issue of an incident can be
+// built from
+type JiraIssueData struct {
+	Title        string
+	Description  string
+	Severity     string
+	Services     []string
+	StartedAt    time.Time
+	DeclaredBy   string
+	PagerDutyURL string
+}
+
+var jiraFieldTemplateFuncs = template.FuncMap{
+	"join":    strings.Join,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"rfc3339": func(t time.Time) string { return t.Format(time.RFC3339) },
+	"date":    func(t time.Time) string { return t.Format("2006-01-02") },
+}
+
+// ******************************************************************************
+// Name				: renderJiraField
+// Description: Function to render a templated value of a JIRA field
+// ******************************************************************************
+func renderJiraField(name string, value string, data JiraIssueData) (string, error) {
+	tmpl, err := template.New(name).Funcs(jiraFieldTemplateFuncs).Parse(value)
+	if err != nil {
+		return "", err
+	}
+	var rendered bytes.Buffer
+	err = tmpl.Execute(&rendered, data)
+	if err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// ******************************************************************************
+// Name				: renderJiraFieldValue
+// Description: Function to render every text inside the value of a custom
+// 							field, so that fields taking objects such as
+// 							{"value": "{{.Severity}}"} can be templated as well
+// ******************************************************************************
+func renderJiraFieldValue(name string, value interface{}, data JiraIssueData) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		return renderJiraField(name, value, data)
+	case map[string]interface{}:
+		rendered := map[string]interface{}{}
+		for key, item := range value {
+			renderedItem, err := renderJiraFieldValue(name, item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = renderedItem
+		}
+		return rendered, nil
+	case []interface{}:
+		var rendered []interface{}
+		for _, item := range value {
+			renderedItem, err := renderJiraFieldValue(name, item, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered = append(rendered, renderedItem)
+		}
+		return rendered, nil
+	}
+	return value, nil
+}
+
+// ******************************************************************************
+// Name				: getJiraIssueDescription
+// Description: Function to get the description of the JIRA issue, rendered from
+// 							jira.fields.description_template when it is set
+// ******************************************************************************
+func getJiraIssueDescription(data JiraIssueData) string {
+	if constants.JIRA.Fields.DescriptionTemplate == "" {
+		return data.Description
+	}
+	description, err := renderJiraField("description", constants.JIRA.Fields.DescriptionTemplate, data)
+	if err != nil {
+		log.Error("getJiraIssueDescription Template Error: ", err)
+		return data.Description
+	}
+	return description
+}
+
+// ******************************************************************************
+// Name				: getJiraIssueComponents
+// Description: Function to get the JIRA components of the affected services
+// 							from the service mappings
+// ******************************************************************************
+func getJiraIssueComponents(services []string) []*jira.Component {
+	var components []*jira.Component
+	seen := map[string]bool{}
+	for _, service := range services {
+		for _, componentID := range getAffectedJiraComponents(service) {
+			if componentID == "" || seen[componentID] {
+				continue
+			}
+			seen[componentID] = true
+			components = append(components, &jira.Component{ID: componentID})
+		}
+	}
+	return components
+}
+
+// ******************************************************************************
+// Name				: getJiraIssueFields
+// Description: Function to build the fields of the JIRA issue of an incident
+// 							from the jira.fields mapping
+// ******************************************************************************
+func getJiraIssueFields(data JiraIssueData) *jira.IssueFields {
+	customFields := tcontainer.NewMarshalMap()
+	for name, value := range constants.JIRA.Fields.CustomFields {
+		rendered, err := renderJiraFieldValue(name, value, data)
+		if err != nil {
+			log.Error("getJiraIssueFields Template Error for ", name, ": ", err)
+			continue
+		}
+		customFields[name] = rendered
+	}
+	fields := &jira.IssueFields{
+		Type: jira.IssueType{
+			ID: constants.JIRA.IssueTypeID, // To set issue type as incident
+		},
+		Project: jira.Project{
+			ID: constants.JIRA.ProjectID, // To set project
+		},
+		Summary:     data.Title,
+		Description: getJiraIssueDescription(data),
+		Labels:      constants.JIRA.Fields.Labels,
+		Components:  getJiraIssueComponents(data.Services),
+		Unknowns:    customFields,
+	}
+	if priority, ok := constants.JIRA.Fields.Priorities[data.Severity]; ok && priority != "" {
+		fields.Priority = &jira.Priority{Name: priority}
+	}
+	return fields
+}
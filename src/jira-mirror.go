@@ -0,0 +1,187 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// Modes of mirroring the messages of an incident channel to JIRA
+const (
+	mirrorModeOff      = "off"
+	mirrorModeAll      = "all"
+	mirrorModeReaction = "reaction"
+)
+
+// ******************************************************************************
+// Name				: getMirrorMode
+// Description: Function to get how the messages of the incident channel are
+// 							mirrored to JIRA. The mode set with the `mirror` command wins
+// 							over jira.mirror.mode
+// ******************************************************************************
+func getMirrorMode(record IncidentRecord) string {
+	if record.MirrorMode != "" {
+		return record.MirrorMode
+	}
+	if constants.JIRA.Mirror.Mode != "" {
+		return constants.JIRA.Mirror.Mode
+	}
+	return mirrorModeOff
+}
+
+// ******************************************************************************
+// Name				: isValidMirrorMode
+// Description: Function to check if a mirror mode is supported
+// ******************************************************************************
+func isValidMirrorMode(mode string) bool {
+	return mode == mirrorModeOff || mode == mirrorModeAll || mode == mirrorModeReaction
+}
+
+// ******************************************************************************
+// Name				: formatMirroredComment
+// Description: Function to build the JIRA comment of a slack message. Replies in
+// 							a thread quote the message they reply to
+// ******************************************************************************
+func formatMirroredComment(channelID string, userID string, text string, timestamp string, threadTimestamp string) string {
+	body := getSlackUserName(userID) + " (Slack, " + getSlackMessageTime(timestamp).UTC().Format("2006-01-02 15:04 MST") + "): " + text
+	if threadTimestamp == "" || threadTimestamp == timestamp {
+		return body
+	}
+	parent, err := getChannelMessage(channelID, threadTimestamp)
+	if err != nil {
+		return body
+	}
+	parentAuthor := parent.Username
+	if parent.User != "" {
+		parentAuthor = getSlackUserName(parent.User)
+	}
+	return "{quote}" + parentAuthor + ": " + parent.Text + "{quote}\n" + body
+}
+
+// ******************************************************************************
+// Name				: isMirrored
+// Description: Function to get the JIRA comment a slack message was mirrored to
+// ******************************************************************************
+func isMirrored(record IncidentRecord, timestamp string) (string, bool) {
+	commentID, ok := record.MirroredComments[timestamp]
+	return commentID, ok
+}
+
+// ******************************************************************************
+// Name				: mirrorMessage
+// Description: Function to add a slack message as comment to the JIRA issue of
+// 							the incident, unless it was mirrored already
+// ******************************************************************************
+func mirrorMessage(record IncidentRecord, userID string, text string, timestamp string, threadTimestamp string) {
+	if _, ok := isMirrored(record, timestamp); ok || text == "" {
+		return
+	}
+	commentID, err := addJiraIssueComment(record.JiraKey, formatMirroredComment(record.ChannelID, userID, text, timestamp, threadTimestamp))
+	if err != nil {
+		return
+	}
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		if record.MirroredComments == nil {
+			record.MirroredComments = map[string]string{}
+		}
+		record.MirroredComments[timestamp] = commentID
+	})
+	if err != nil {
+		log.Error("mirrorMessage Error: ", err)
+	}
+}
+
+// ******************************************************************************
+// Name				: mirrorMessageEvent
+// Description: Function to reconcile the JIRA comments of an incident with a new,
+// 							edited or deleted message of its channel
+// ******************************************************************************
+func mirrorMessageEvent(event *slackevents.MessageEvent) {
+	record, err := incidentStore.FindByChannel(event.Channel)
+	if err != nil || record.JiraKey == "" {
+		return
+	}
+	mode := getMirrorMode(record)
+
+	switch event.SubType {
+	case "", "thread_broadcast":
+		if mode == mirrorModeAll && event.BotID == "" {
+			mirrorMessage(record, event.User, event.Text, event.TimeStamp, event.ThreadTimeStamp)
+		}
+	case "message_changed":
+		if event.Message == nil {
+			return
+		}
+		commentID, ok := isMirrored(record, event.Message.TimeStamp)
+		if !ok {
+			return
+		}
+		updateJiraIssueComment(record.JiraKey, commentID, formatMirroredComment(record.ChannelID, event.Message.User, event.Message.Text, event.Message.TimeStamp, event.Message.ThreadTimeStamp))
+	case "message_deleted":
+		if event.PreviousMessage == nil {
+			return
+		}
+		commentID, ok := isMirrored(record, event.PreviousMessage.TimeStamp)
+		if !ok || deleteJiraIssueComment(record.JiraKey, commentID) != nil {
+			return
+		}
+		_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+			delete(record.MirroredComments, event.PreviousMessage.TimeStamp)
+		})
+		if err != nil {
+			log.Error("mirrorMessageEvent Error: ", err)
+		}
+	}
+}
+
+// ******************************************************************************
+// Name				: mirrorReactionEvent
+// Description: Function to mirror a message that was reacted to with the emoji
+// 							of jira.mirror.reaction
+// ******************************************************************************
+func mirrorReactionEvent(event *slackevents.ReactionAddedEvent) {
+	if event.Item.Type != "message" || constants.JIRA.Mirror.Reaction == "" || event.Reaction != constants.JIRA.Mirror.Reaction {
+		return
+	}
+	record, err := incidentStore.FindByChannel(event.Item.Channel)
+	if err != nil || record.JiraKey == "" || getMirrorMode(record) == mirrorModeOff {
+		return
+	}
+	message, err := getChannelMessage(event.Item.Channel, event.Item.Timestamp)
+	if err != nil || message.BotID != "" {
+		return
+	}
+	mirrorMessage(record, message.User, message.Text, message.Timestamp, message.ThreadTimestamp)
+}
+
+// ******************************************************************************
+// Name				: mirrorCommandService
+// Description: Function to change how the messages of the incident channel are
+// 							mirrored to JIRA
+// ******************************************************************************
+func mirrorCommandService(s slack.SlashCommand, arguments []string) {
+	record, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.MirrorMode = arguments[1]
+	})
+	if err != nil {
+		msg := "ERROR!! Error updating the incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	var responseText string
+	switch arguments[1] {
+	case mirrorModeAll:
+		responseText = "Messages of this channel are now mirrored to JIRA"
+	case mirrorModeReaction:
+		responseText = "Messages of this channel reacted with :" + constants.JIRA.Mirror.Reaction + ": are now mirrored to JIRA"
+	default:
+		responseText = "Messages of this channel are no longer mirrored to JIRA"
+	}
+	response := SlashResponse{"in_channel", responseText}
+	slackCommandResponse(response, s)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/andygrunwald/go-jira"
+	log "github.com/sirupsen/logrus"
+)
+
+// jiraUserCache maps emails to the JIRA users they resolved to
+var jiraUserCache sync.Map
+
+var errJiraUserNotFound = errors.New("JiraUserNotFound")
+
+// ******************************************************************************
+// Name				: getJiraEmail
+// Description: Function to get the email of the JIRA user of a slack email,
+// 							following jira.users.email_aliases and then
+// 							jira.users.domain_aliases. Also returns whether the email
+// 							was configured in jira.users.email_aliases
+// ******************************************************************************
+func getJiraEmail(slackEmail string) (string, bool) {
+	email := strings.ToLower(strings.TrimSpace(slackEmail))
+	for alias, jiraEmail := range constants.JIRA.Users.EmailAliases {
+		if strings.EqualFold(alias, email) {
+			return strings.ToLower(jiraEmail), true
+		}
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email, false
+	}
+	for slackDomain, jiraDomain := range constants.JIRA.Users.DomainAliases {
+		if strings.EqualFold(slackDomain, email[at+1:]) {
+			return email[:at+1] + strings.ToLower(jiraDomain), false
+		}
+	}
+	return email, false
+}
+
+// ******************************************************************************
+// Name				: getJiraUserForSlackUser
+// Description: Function to find the JIRA user of a slack user from the email of
+// 							the slack profile
+// ******************************************************************************
+func getJiraUserForSlackUser(userID string) (jira.User, error) {
+	slackEmail, err := getSlackUserEmail(userID)
+	if err != nil {
+		return jira.User{}, err
+	}
+	if slackEmail == "" {
+		return jira.User{}, errJiraUserNotFound
+	}
+	email, configured := getJiraEmail(slackEmail)
+	if user, ok := jiraUserCache.Load(email); ok {
+		return user.(jira.User), nil
+	}
+	user, err := findJiraUser(email, configured)
+	if err != nil {
+		return jira.User{}, err
+	}
+	jiraUserCache.Store(email, user)
+	return user, nil
+}
+
+// ******************************************************************************
+// Name				: getJiraCommentForSlackUser
+// Description: Function to build a JIRA comment written by a slack user. JIRA
+// 							posts every comment as the Falcon user, so the slack display
+// 							name is put in front, together with the name of the JIRA user
+// 							of the slack user when it differs. The name is plain text so
+// 							that authors are not notified about their own comments
+// ******************************************************************************
+func getJiraCommentForSlackUser(userID string, text string) jira.Comment {
+	author := getSlackUserName(userID)
+	user, err := getJiraUserForSlackUser(userID)
+	if err != nil {
+		log.Warn("No JIRA user found for slack user ", userID, ": ", err)
+		return jira.Comment{Body: author + ": " + text}
+	}
+	if user.DisplayName == "" || strings.EqualFold(user.DisplayName, author) {
+		return jira.Comment{Body: author + ": " + text}
+	}
+	return jira.Comment{Body: author + " (" + user.DisplayName + "): " + text}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// How long comments posted by Falcon are remembered to keep them from being
+// posted back to Slack
+const falconJiraCommentTTL = 10 * time.Minute
+
+// Longest JIRA comment, in characters, posted in full to the incident channel
+const maxJiraCommentLength = 2000
+
+// falconJiraComments maps the IDs of comments posted by Falcon to when they
+// were posted
+var falconJiraComments sync.Map
+
+// postedJiraComments maps the IDs of JIRA comments posted to Slack to when they
+// were posted, as a comment may be sent both as comment and as issue update
+var postedJiraComments sync.Map
+
+// falconJiraUser is the JIRA user Falcon is authenticated as, loaded once
+var falconJiraUser struct {
+	sync.Mutex
+	user *jira.User
+}
+
+// JiraWebhookPayload is the body of a webhook sent by JIRA
+type JiraWebhookPayload struct {
+	WebhookEvent       string               `json:"webhookEvent"`
+	IssueEventTypeName string               `json:"issue_event_type_name"`
+	User               *jira.User           `json:"user"`
+	Issue              JiraWebhookIssue     `json:"issue"`
+	Changelog          JiraWebhookChangelog `json:"changelog"`
+	Comment            *jira.Comment        `json:"comment"`
+}
+
+// JiraWebhookIssue identifies the issue a webhook is about
+type JiraWebhookIssue struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+// JiraWebhookChangelog lists the fields changed by an issue update
+type JiraWebhookChangelog struct {
+	Items []jira.ChangelogItems `json:"items"`
+}
+
+// ******************************************************************************
+// Name				: rememberFalconJiraComment
+// Description: Function to remember a comment posted by Falcon, so that its
+// 							webhook is not posted back to Slack
+// ******************************************************************************
+func rememberFalconJiraComment(commentID string) {
+	now := time.Now()
+	falconJiraComments.Store(commentID, now)
+	falconJiraComments.Range(func(key, value interface{}) bool {
+		if now.Sub(value.(time.Time)) > falconJiraCommentTTL {
+			falconJiraComments.Delete(key)
+		}
+		return true
+	})
+}
+
+// ******************************************************************************
+// Name				: isNewJiraComment
+// Description: Function to check if a JIRA comment was not yet posted to Slack
+// 							and remember it as posted
+// ******************************************************************************
+func isNewJiraComment(commentID string) bool {
+	now := time.Now()
+	_, posted := postedJiraComments.LoadOrStore(commentID, now)
+	postedJiraComments.Range(func(key, value interface{}) bool {
+		if now.Sub(value.(time.Time)) > falconJiraCommentTTL {
+			postedJiraComments.Delete(key)
+		}
+		return true
+	})
+	return !posted
+}
+
+// ******************************************************************************
+// Name				: isFalconJiraUser
+// Description: Function to check if a JIRA user is the one Falcon is
+// 							authenticated as
+// ******************************************************************************
+func isFalconJiraUser(user *jira.User) bool {
+	if user == nil {
+		return false
+	}
+	falconJiraUser.Lock()
+	if falconJiraUser.user == nil {
+		falconJiraUser.user, _ = getJiraSelf()
+	}
+	self := falconJiraUser.user
+	falconJiraUser.Unlock()
+	if self == nil {
+		username := os.Getenv("JIRA_USERNAME")
+		return username != "" && (strings.EqualFold(user.Name, username) || strings.EqualFold(user.EmailAddress, username))
+	}
+	return (self.AccountID != "" && user.AccountID == self.AccountID) || (self.Name != "" && user.Name == self.Name)
+}
+
+// ******************************************************************************
+// Name				: isFalconJiraComment
+// Description: Function to check if a comment was posted by Falcon, either from
+// 							a command or by mirroring the incident channel
+// ******************************************************************************
+func isFalconJiraComment(record IncidentRecord, comment *jira.Comment) bool {
+	if _, ok := falconJiraComments.Load(comment.ID); ok {
+		return true
+	}
+	for _, commentID := range record.MirroredComments {
+		if commentID == comment.ID {
+			return true
+		}
+	}
+	return isFalconJiraUser(&comment.Author)
+}
+
+// ******************************************************************************
+// Name				: getJiraUserDisplayName
+// Description: Function to get a readable name of a JIRA user for messages
+// ******************************************************************************
+func getJiraUserDisplayName(user *jira.User) string {
+	switch {
+	case user == nil:
+		return "Someone"
+	case user.DisplayName != "":
+		return user.DisplayName
+	case user.Name != "":
+		return user.Name
+	}
+	return user.AccountID
+}
+
+// ******************************************************************************
+// Name				: getJiraChangeMessages
+// Description: Function to describe the status and assignee changes of an
+// 							issue update
+// ******************************************************************************
+func getJiraChangeMessages(payload JiraWebhookPayload) []string {
+	var messages []string
+	issueLink := "<" + constants.JIRA.Endpoint + "/browse/" + payload.Issue.Key + "|" + payload.Issue.Key + ">"
+	author := getJiraUserDisplayName(payload.User)
+	for _, item := range payload.Changelog.Items {
+		switch strings.ToLower(item.Field) {
+		case "status":
+			messages = append(messages, "JIRA: "+author+" moved "+issueLink+" from *"+valueOrNone(item.FromString)+"* to *"+valueOrNone(item.ToString)+"*")
+		case "assignee":
+			if item.ToString == "" {
+				messages = append(messages, "JIRA: "+author+" unassigned "+issueLink)
+			} else {
+				messages = append(messages, "JIRA: "+author+" assigned "+issueLink+" to *"+item.ToString+"*")
+			}
+		case "resolution":
+			if item.ToString != "" {
+				messages = append(messages, "JIRA: "+author+" set the resolution of "+issueLink+" to *"+item.ToString+"*")
+			}
+		}
+	}
+	return messages
+}
+
+// ******************************************************************************
+// Name				: getJiraCommentMessage
+// Description: Function to describe a comment added in JIRA
+// ******************************************************************************
+func getJiraCommentMessage(payload JiraWebhookPayload) string {
+	body := strings.TrimSpace(payload.Comment.Body)
+	if runes := []rune(body); len(runes) > maxJiraCommentLength {
+		body = string(runes[:maxJiraCommentLength]) + "…"
+	}
+	issueLink := "<" + constants.JIRA.Endpoint + "/browse/" + payload.Issue.Key + "?focusedCommentId=" + payload.Comment.ID + "|" + payload.Issue.Key + ">"
+	return "JIRA: " + getJiraUserDisplayName(&payload.Comment.Author) + " commented on " + issueLink + ":\n>" + strings.Replace(body, "\n", "\n>", -1)
+}
+
+// ******************************************************************************
+// Name				: jiraWebhookService
+// Description: Function to post the changes made in JIRA to the channel of the
+// 							incident of the issue. Changes made by Falcon are skipped
+// ******************************************************************************
+func jiraWebhookService(payload JiraWebhookPayload) {
+	if payload.Issue.Key == "" {
+		return
+	}
+	record, err := incidentStore.Find(func(record *IncidentRecord) bool {
+		return strings.EqualFold(record.JiraKey, payload.Issue.Key)
+	})
+	if err != nil || record.ChannelID == "" || !record.ArchivedAt.IsZero() {
+		return
+	}
+
+	var messages []string
+	switch payload.WebhookEvent {
+	case "comment_created":
+		if payload.Comment == nil || isFalconJiraComment(record, payload.Comment) || !isNewJiraComment(payload.Comment.ID) {
+			return
+		}
+		messages = append(messages, getJiraCommentMessage(payload))
+	case "jira:issue_updated":
+		if !isFalconJiraUser(payload.User) {
+			messages = getJiraChangeMessages(payload)
+		}
+		// JIRA Server sends new comments as issue updates with a comment
+		commented := payload.IssueEventTypeName != "issue_comment_edited" && payload.IssueEventTypeName != "issue_comment_deleted"
+		if commented && payload.Comment != nil && !isFalconJiraComment(record, payload.Comment) && isNewJiraComment(payload.Comment.ID) {
+			messages = append(messages, getJiraCommentMessage(payload))
+		}
+	}
+	for _, message := range messages {
+		postMessageToChannel(record.ChannelID, message)
+	}
+}
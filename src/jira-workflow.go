@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Transitions used when jira.workflow is not configured
+var defaultJiraWorkflow = map[string]JiraTransitionConfig{
+	"resolved": {Transition: "close", Resolution: "Done"},
+}
+
+// ******************************************************************************
+// Name				: getJiraProjectKey
+// Description: Function to get the project of a JIRA issue from its key
+// ******************************************************************************
+func getJiraProjectKey(issueKey string) string {
+	if dash := strings.LastIndex(issueKey, "-"); dash > 0 {
+		return issueKey[:dash]
+	}
+	return issueKey
+}
+
+// ******************************************************************************
+// Name				: getJiraWorkflow
+// Description: Function to get the transitions of the incident phases for the
+// 							project of a JIRA issue. The workflow of the project in
+// 							jira.workflow.projects, keyed by project key, wins over
+// 							jira.workflow.default
+// ******************************************************************************
+func getJiraWorkflow(issueKey string) map[string]JiraTransitionConfig {
+	if workflow, ok := constants.JIRA.Workflow.Projects[getJiraProjectKey(issueKey)]; ok {
+		return workflow
+	}
+	if len(constants.JIRA.Workflow.Default) > 0 {
+		return constants.JIRA.Workflow.Default
+	}
+	return defaultJiraWorkflow
+}
+
+// ******************************************************************************
+// Name				: transitionJiraIssue
+// Description: Function to move a JIRA issue to an incident phase. The
+// 							transition is matched by ID or name. Phases without a
+// 							configured transition leave the issue unchanged
+// ******************************************************************************
+func transitionJiraIssue(issueKey string, phase string) error {
+	config, ok := getJiraWorkflow(issueKey)[phase]
+	if !ok || config.Transition == "" {
+		return nil
+	}
+	return applyJiraTransition(issueKey, config)
+}
+
+// ******************************************************************************
+// Name				: closeJiraIssueAsDuplicate
+// Description: Function to close the JIRA issue of a duplicate incident with
+// 							the transition of the "duplicate" phase, or else with the
+// 							transition of the "resolved" phase and the Duplicate resolution
+// ******************************************************************************
+func closeJiraIssueAsDuplicate(issueKey string) error {
+	workflow := getJiraWorkflow(issueKey)
+	config, ok := workflow["duplicate"]
+	if !ok {
+		config = workflow["resolved"]
+		config.Resolution = "Duplicate"
+	}
+	if config.Transition == "" {
+		return errors.New("no transition configured for the duplicate or resolved phase")
+	}
+	return applyJiraTransition(issueKey, config)
+}
+
+// ******************************************************************************
+// Name				: applyJiraTransition
+// Description: Function to move a JIRA issue with a configured transition and
+// 							report the available transitions when it cannot be taken
+// ******************************************************************************
+func applyJiraTransition(issueKey string, config JiraTransitionConfig) error {
+	transitions, err := getJiraTransitions(issueKey)
+	if err != nil {
+		return err
+	}
+	var available []string
+	for _, transition := range transitions {
+		if transition.ID == config.Transition || strings.EqualFold(transition.Name, config.Transition) {
+			return doJiraTransition(issueKey, transition.ID, config.Resolution)
+		}
+		available = append(available, transition.Name+" ("+transition.ID+")")
+	}
+	err = fmt.Errorf("transition %q is not available for %s, available transitions are: %s", config.Transition, issueKey, strings.Join(available, ", "))
+	log.Error("applyJiraTransition Error: ", err)
+	return err
+}
+
+// ******************************************************************************
+// Name				: transitionIncidentJiraIssue
+// Description: Function to move the JIRA issue of an incident to an incident
+// 							phase, unless it was moved to that phase already
+// ******************************************************************************
+func transitionIncidentJiraIssue(channelID string, issueKey string, phase string) error {
+	record, err := incidentStore.FindByChannel(channelID)
+	if err == nil && record.JiraPhase == phase {
+		return nil
+	}
+	found := err == nil
+	err = transitionJiraIssue(issueKey, phase)
+	if err != nil || !found {
+		return err
+	}
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.JiraPhase = phase
+	})
+	if err != nil {
+		log.Error("transitionIncidentJiraIssue Error: ", err)
+	}
+	return nil
+}
@@ -12,12 +12,18 @@ func main() {
 	// statusPageMappingsInitializer()
 	// serviceMappingsInitializer()
 	constantsInitializer()
+	jiraInitializer()
+	incidentStoreInitializer()
+	schedulerInitializer()
 
 	router := mux.NewRouter()
 	router.HandleFunc("/healthcheck", healthcheck).Methods("GET")
 	router.HandleFunc("/pagerduty/webhook", pagerdutyController).Methods("POST")
+	router.HandleFunc("/jira/webhook", jiraWebhookController).Methods("POST")
 	router.HandleFunc("/updateConfig", updateConfigController).Methods("GET")
 	router.HandleFunc("/slack/comment", slackController)
+	router.HandleFunc("/slack/interactive", slackInteractionController).Methods("POST")
+	router.HandleFunc("/slack/events", slackEventsController).Methods("POST")
 	log.Info("Falcon Started on port : ", constants.ApplicationPort)
 	log.Fatal(http.ListenAndServe(":8000", router))
 }
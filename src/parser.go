@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/slack-go/slack"
 )
@@ -14,7 +15,7 @@ import (
 func parseCommandArguments(s slack.SlashCommand, arguments []string) (string, error) {
 	// Format check for `comments` command
 	if arguments[0] == "comment" {
-		if len(arguments) != 3 {
+		if !(len(arguments) == 3 || (len(arguments) == 4 && isPageArgument(arguments[3]))) {
 			response := constants.ValidationMessages.InvalidNumberOfArguments + ". " + constants.ValidationMessages.CommentCommandFormat + "\n" + constants.ValidationMessages.UseHelp
 			return response, errors.New("Invalid Arguments")
 		}
@@ -22,11 +23,21 @@ func parseCommandArguments(s slack.SlashCommand, arguments []string) (string, er
 			response := constants.ValidationMessages.InvalidStatus + ". " + constants.ValidationMessages.AllowedStatusPageStatus + "\n " + constants.ValidationMessages.UseHelp
 			return response, errors.New("Invalid Arguments")
 		}
+		if isTemplateArgument(arguments[2]) && getTemplateName(arguments[2]) == "" {
+			response := constants.ValidationMessages.InvalidTemplate + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+		if len(arguments) == 4 {
+			if _, err := getStatusPage(getPageArgument(arguments)); err != nil {
+				response := constants.ValidationMessages.InvalidPage + "\n" + constants.ValidationMessages.UseHelp
+				return response, errors.New("Invalid Arguments")
+			}
+		}
 	}
 
 	// Format check for `comment-statuspage` command
 	if arguments[0] == "comment-statuspage" {
-		if len(arguments) != 3 {
+		if !(len(arguments) == 3 || (len(arguments) == 4 && isPageArgument(arguments[3]))) {
 			response := constants.ValidationMessages.InvalidNumberOfArguments + ". " + constants.ValidationMessages.CommentStatusPageCommandFormat + "\n" + constants.ValidationMessages.UseHelp
 			return response, errors.New("Invalid Arguments")
 		}
@@ -34,6 +45,16 @@ func parseCommandArguments(s slack.SlashCommand, arguments []string) (string, er
 			response := constants.ValidationMessages.InvalidStatus + ". " + constants.ValidationMessages.AllowedStatusPageStatus + "\n " + constants.ValidationMessages.UseHelp
 			return response, errors.New("Invalid Arguments")
 		}
+		if isTemplateArgument(arguments[2]) && getTemplateName(arguments[2]) == "" {
+			response := constants.ValidationMessages.InvalidTemplate + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+		if len(arguments) == 4 {
+			if _, err := getStatusPage(getPageArgument(arguments)); err != nil {
+				response := constants.ValidationMessages.InvalidPage + "\n" + constants.ValidationMessages.UseHelp
+				return response, errors.New("Invalid Arguments")
+			}
+		}
 	}
 
 	// Format check for `comment-jira` command
@@ -48,6 +69,102 @@ func parseCommandArguments(s slack.SlashCommand, arguments []string) (string, er
 		}
 	}
 
+	// Format check for `visibility` command
+	if arguments[0] == "visibility" {
+		if len(arguments) != 2 {
+			response := constants.ValidationMessages.InvalidNumberOfArguments + ". " + constants.ValidationMessages.VisibilityCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+		if !isValidVisibility(arguments[1]) {
+			response := constants.ValidationMessages.InvalidVisibility + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+	}
+
+	// Format check for `approve` and `reject` commands
+	if arguments[0] == "approve" || arguments[0] == "reject" {
+		if len(arguments) != 2 {
+			response := constants.ValidationMessages.InvalidNumberOfArguments + ". " + constants.ValidationMessages.ApproveCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+	}
+
+	// Format check for `severity` command
+	if arguments[0] == "severity" {
+		if len(arguments) != 2 {
+			response := constants.ValidationMessages.InvalidNumberOfArguments + ". " + constants.ValidationMessages.SeverityCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+		if !(arguments[1] == "minor" || arguments[1] == "major" || arguments[1] == "critical") {
+			response := constants.ValidationMessages.IncorrectCommandFormat + ". " + constants.ValidationMessages.SeverityCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+	}
+
+	// Format check for `keep-open` command
+	if arguments[0] == "keep-open" {
+		if len(arguments) > 2 {
+			response := constants.ValidationMessages.InvalidNumberOfArguments + ". " + constants.ValidationMessages.KeepOpenCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+		if len(arguments) == 2 {
+			if duration, err := time.ParseDuration(arguments[1]); err != nil || duration <= 0 {
+				response := constants.ValidationMessages.IncorrectCommandFormat + ". " + constants.ValidationMessages.KeepOpenCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+				return response, errors.New("Invalid Arguments")
+			}
+		}
+	}
+
+	// Format check for `reminders` command
+	if arguments[0] == "reminders" {
+		if !((len(arguments) == 3 && arguments[1] == "snooze") || (len(arguments) == 2 && (arguments[1] == "off" || arguments[1] == "on"))) {
+			response := constants.ValidationMessages.IncorrectCommandFormat + ". " + constants.ValidationMessages.RemindersCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+		if arguments[1] == "snooze" {
+			if duration, err := time.ParseDuration(arguments[2]); err != nil || duration <= 0 {
+				response := constants.ValidationMessages.IncorrectCommandFormat + ". " + constants.ValidationMessages.RemindersCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+				return response, errors.New("Invalid Arguments")
+			}
+		}
+	}
+
+	// Format check for `action` command
+	if arguments[0] == "action" {
+		if len(arguments) < 2 {
+			response := constants.ValidationMessages.InvalidNumberOfArguments + ". " + constants.ValidationMessages.ActionCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+		if _, err := parseActionArguments(arguments[2:]); err != nil {
+			response := constants.ValidationMessages.IncorrectCommandFormat + ". " + constants.ValidationMessages.ActionCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+	}
+
+	// Format check for `merge` command
+	if arguments[0] == "merge" && len(arguments) != 2 {
+		response := constants.ValidationMessages.InvalidNumberOfArguments + ". " + constants.ValidationMessages.MergeCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+		return response, errors.New("Invalid Arguments")
+	}
+
+	// Format check for `mirror` command
+	if arguments[0] == "mirror" {
+		if len(arguments) != 2 || !isValidMirrorMode(arguments[1]) {
+			response := constants.ValidationMessages.IncorrectCommandFormat + ". " + constants.ValidationMessages.MirrorCommandFormat + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+	}
+
+	// A trailing visibility argument is only allowed for the `issue` command
+	if arguments[0] == "issue" {
+		var visibility string
+		arguments, visibility = splitVisibilityArgument(arguments)
+		if visibility != "" && !isValidVisibility(visibility) {
+			response := constants.ValidationMessages.InvalidVisibility + "\n" + constants.ValidationMessages.UseHelp
+			return response, errors.New("Invalid Arguments")
+		}
+	}
+
 	// Format check for `issue` command
 	if arguments[0] == "issue" || arguments[0] == "statuspage-incident" {
 		if len(arguments) < 2 || len(arguments) > 4 {
@@ -78,21 +195,22 @@ func parseCommandArguments(s slack.SlashCommand, arguments []string) (string, er
 // ******************************************************************************
 func parseSubCommandArguments(arguments []string) (string, []string) {
 	var severity string
-	var componentIDList []string
+	var services []string
+	arguments, _ = splitVisibilityArgument(arguments)
 
 	// To set severity or components list if specified in the command
 	if len(arguments) == 3 {
 		if arguments[2] == "minor" || arguments[2] == "major" || arguments[2] == "critical" {
 			severity = arguments[2]
 		} else {
-			componentIDList = parseAffectedComponents(arguments[2])
+			services = parseAffectedComponents(arguments[2])
 		}
 	}
 
 	if len(arguments) == 4 {
 		severity = arguments[2]
-		componentIDList = parseAffectedComponents(arguments[3])
+		services = parseAffectedComponents(arguments[3])
 	}
 
-	return severity, componentIDList
+	return severity, services
 }
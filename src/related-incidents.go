@@ -0,0 +1,242 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultRelatedIncidentsWindow = 7 * 24 * time.Hour
+
+const defaultRelatedIncidentsLinkType = "Relates"
+
+// Most incidents found in JIRA that are linked to a new incident
+const maxRelatedJiraIssues = 10
+
+// RelatedIncident is a past incident that may have the same cause as a new one
+type RelatedIncident struct {
+	JiraKey   string
+	Title     string
+	ChannelID string
+	CreatedAt time.Time
+}
+
+// ******************************************************************************
+// Name				: getRelatedIncidentsWindow
+// Description: Function to get how far back related incidents are searched for.
+// 							Returns 0 when the search is turned off
+// ******************************************************************************
+func getRelatedIncidentsWindow() time.Duration {
+	configured := constants.RelatedIncidents.Window
+	if configured == "" {
+		return defaultRelatedIncidentsWindow
+	}
+	if configured == "off" {
+		return 0
+	}
+	window, err := time.ParseDuration(configured)
+	if err != nil || window <= 0 {
+		log.Error("getRelatedIncidentsWindow Invalid Window: ", configured)
+		return defaultRelatedIncidentsWindow
+	}
+	return window
+}
+
+// ******************************************************************************
+// Name				: getIncidentServices
+// Description: Function to get the services and components an incident affects,
+// 							including its pagerduty service
+// ******************************************************************************
+func getIncidentServices(record IncidentRecord) []string {
+	services := append([]string{}, record.Services...)
+	if record.PDServiceID != "" {
+		services = append(services, record.PDServiceID)
+	}
+	return services
+}
+
+// ******************************************************************************
+// Name				: getSPComponentIDs
+// Description: Function to get the IDs of StatusPage components, to keep them
+// 							with an incident
+// ******************************************************************************
+func getSPComponentIDs(components []SPComponent) []string {
+	var componentIDs []string
+	for _, component := range components {
+		if component.ID != "" {
+			componentIDs = append(componentIDs, component.ID)
+		}
+	}
+	return componentIDs
+}
+
+// ******************************************************************************
+// Name				: sharesAny
+// Description: Function to check if two lists have a common non-empty value,
+// 							ignoring case
+// ******************************************************************************
+func sharesAny(values []string, others []string) bool {
+	for _, value := range values {
+		for _, other := range others {
+			if value != "" && strings.EqualFold(value, other) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ******************************************************************************
+// Name				: sharesService
+// Description: Function to check if two incidents affect a common service or
+// 							StatusPage component
+// ******************************************************************************
+func sharesService(a IncidentRecord, b IncidentRecord) bool {
+	return sharesAny(getIncidentServices(a), getIncidentServices(b)) || sharesAny(a.SPComponentIDs, b.SPComponentIDs)
+}
+
+// ******************************************************************************
+// Name				: findRelatedStoredIncidents
+// Description: Function to find the incidents in the store that affected the
+// 							same services within the window
+// ******************************************************************************
+func findRelatedStoredIncidents(record IncidentRecord, since time.Time) []RelatedIncident {
+	records := incidentStore.List(func(other *IncidentRecord) bool {
+		return other.ID != record.ID && other.CreatedAt.After(since) && sharesService(record, *other)
+	})
+	var related []RelatedIncident
+	for _, other := range records {
+		related = append(related, RelatedIncident{
+			JiraKey:   other.JiraKey,
+			Title:     other.Title,
+			ChannelID: other.ChannelID,
+			CreatedAt: other.CreatedAt,
+		})
+	}
+	return related
+}
+
+// ******************************************************************************
+// Name				: getRelatedIncidentsJQL
+// Description: Function to build the JQL that finds the JIRA issues of the
+// 							incidents on the same JIRA components within the window.
+// 							Returns an empty query when the services have no components
+// ******************************************************************************
+func getRelatedIncidentsJQL(record IncidentRecord, window time.Duration) string {
+	var componentIDs []string
+	for _, component := range getJiraIssueComponents(getIncidentServices(record)) {
+		componentIDs = append(componentIDs, component.ID)
+	}
+	if len(componentIDs) == 0 {
+		return ""
+	}
+	clauses := []string{
+		"project = " + constants.JIRA.ProjectID,
+		"component in (" + strings.Join(componentIDs, ", ") + ")",
+		"created >= -" + strconv.Itoa(int(window.Minutes())) + "m",
+	}
+	if record.JiraKey != "" {
+		clauses = append(clauses, "key != "+record.JiraKey)
+	}
+	if constants.RelatedIncidents.JQL != "" {
+		clauses = append(clauses, "("+constants.RelatedIncidents.JQL+")")
+	}
+	return strings.Join(clauses, " AND ") + " ORDER BY created DESC"
+}
+
+// ******************************************************************************
+// Name				: findRelatedIncidents
+// Description: Function to find the past incidents related to a new incident in
+// 							the incident store and in JIRA, without duplicates
+// ******************************************************************************
+func findRelatedIncidents(record IncidentRecord, window time.Duration) []RelatedIncident {
+	related := findRelatedStoredIncidents(record, record.CreatedAt.Add(-window))
+	seen := map[string]bool{record.JiraKey: true}
+	for _, incident := range related {
+		seen[incident.JiraKey] = true
+	}
+	jql := getRelatedIncidentsJQL(record, window)
+	if jql == "" {
+		return related
+	}
+	issues, err := searchJiraIssues(jql, maxRelatedJiraIssues)
+	if err != nil {
+		return related
+	}
+	for _, issue := range issues {
+		if seen[issue.Key] {
+			continue
+		}
+		seen[issue.Key] = true
+		incident := RelatedIncident{JiraKey: issue.Key}
+		if issue.Fields != nil {
+			incident.Title = issue.Fields.Summary
+			incident.CreatedAt = time.Time(issue.Fields.Created)
+		}
+		related = append(related, incident)
+	}
+	return related
+}
+
+// ******************************************************************************
+// Name				: formatRelatedIncident
+// Description: Function to describe a related incident in a message
+// ******************************************************************************
+func formatRelatedIncident(incident RelatedIncident) string {
+	var parts []string
+	if incident.JiraKey != "" {
+		parts = append(parts, "<"+constants.JIRA.Endpoint+"/browse/"+incident.JiraKey+"|"+incident.JiraKey+">")
+	}
+	if incident.Title != "" {
+		parts = append(parts, incident.Title)
+	}
+	if incident.ChannelID != "" {
+		parts = append(parts, "<#"+incident.ChannelID+">")
+	}
+	if !incident.CreatedAt.IsZero() {
+		parts = append(parts, "("+incident.CreatedAt.UTC().Format("2006-01-02")+")")
+	}
+	return "• " + strings.Join(parts, " ")
+}
+
+// ******************************************************************************
+// Name				: linkRelatedIncidents
+// Description: Function to point the channel of a new incident to the past
+// 							incidents on the same services and link their JIRA issues
+// ******************************************************************************
+func linkRelatedIncidents(record IncidentRecord) {
+	window := getRelatedIncidentsWindow()
+	if window == 0 || record.ChannelID == "" {
+		return
+	}
+	related := findRelatedIncidents(record, window)
+	if len(related) == 0 {
+		return
+	}
+
+	linkType := constants.RelatedIncidents.LinkType
+	if linkType == "" {
+		linkType = defaultRelatedIncidentsLinkType
+	}
+	lines := []string{"Possibly related incidents from the last " + window.String() + ":"}
+	var linked []string
+	for _, incident := range related {
+		lines = append(lines, formatRelatedIncident(incident))
+		if record.JiraKey == "" || incident.JiraKey == "" {
+			continue
+		}
+		if linkJiraIssues(record.JiraKey, incident.JiraKey, linkType) == nil {
+			linked = append(linked, incident.JiraKey)
+		}
+	}
+	postMessageToChannel(record.ChannelID, strings.Join(lines, "\n"))
+
+	_, err := incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.RelatedJiraKeys = append(record.RelatedJiraKeys, linked...)
+	})
+	if err != nil {
+		log.Error("linkRelatedIncidents Error: ", err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// ******************************************************************************
+// Name				: getReminderInterval
+// Description: Function to get how often the incident channel is reminded to
+// 							post a status update for the severity of the incident.
+// 							Returns 0 when there are no reminders for the severity
+// ******************************************************************************
+func getReminderInterval(severity string) time.Duration {
+	configured, ok := constants.Reminders.Intervals[severity]
+	if !ok {
+		configured = constants.Reminders.Default
+	}
+	if configured == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(configured)
+	if err != nil || interval <= 0 {
+		log.Error("getReminderInterval Invalid Interval: ", configured)
+		return 0
+	}
+	return interval
+}
+
+// ******************************************************************************
+// Name				: getLastActivity
+// Description: Function to get when the incident last had a status update or a
+// 							reminder
+// ******************************************************************************
+func getLastActivity(record IncidentRecord) time.Time {
+	last := record.CreatedAt
+	for _, j := range []time.Time{record.LastUpdateAt, record.LastReminderAt} {
+		if j.After(last) {
+			last = j
+		}
+	}
+	return last
+}
+
+// ******************************************************************************
+// Name				: remindStatusUpdates
+// Description: Scheduled job to remind the commanders of open incidents to post
+// 							a status update when none was posted for a while
+// ******************************************************************************
+func remindStatusUpdates(now time.Time) {
+	records := incidentStore.List(func(record *IncidentRecord) bool {
+		return record.ChannelID != "" && record.Status != "resolved" && record.ArchivedAt.IsZero() && !record.RemindersDisabled
+	})
+	for _, record := range records {
+		interval := getReminderInterval(record.Severity)
+		if interval == 0 || now.Before(record.RemindersSnoozedUntil) || now.Sub(getLastActivity(record)) < interval {
+			continue
+		}
+		sendStatusUpdateReminder(record, interval)
+	}
+}
+
+// ******************************************************************************
+// Name				: sendStatusUpdateReminder
+// Description: Function to ping the commander of the incident for a status
+// 							update
+// ******************************************************************************
+func sendStatusUpdateReminder(record IncidentRecord, interval time.Duration) {
+	mention := "<!here>"
+	if record.CommanderID != "" {
+		mention = "<@" + record.CommanderID + ">"
+	} else if record.AcknowledgedBy != "" {
+		mention = "<@" + record.AcknowledgedBy + ">"
+	}
+	text := mention + " no status update has been posted in the last " + interval.String() +
+		". Please post one with /falcon \"comment\" or /falcon \"comment-statuspage\"." +
+		" Use /falcon \"reminders\" \"snooze\" \"<duration>\" or /falcon \"reminders\" \"off\" to silence these reminders."
+	_, err := postMessageToChannel(record.ChannelID, text)
+	if err != nil {
+		return
+	}
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.LastReminderAt = time.Now()
+	})
+	if err != nil {
+		log.Error("sendStatusUpdateReminder Error: ", err)
+	}
+}
+
+// ******************************************************************************
+// Name				: remindersCommandService
+// Description: Function to snooze, disable or enable the status update reminders
+// 							of the incident
+// ******************************************************************************
+func remindersCommandService(s slack.SlashCommand, arguments []string) {
+	record, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	var responseText string
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		switch arguments[1] {
+		case "snooze":
+			duration, _ := time.ParseDuration(arguments[2])
+			record.RemindersSnoozedUntil = time.Now().Add(duration)
+			record.RemindersDisabled = false
+			responseText = "Status update reminders snoozed until " + record.RemindersSnoozedUntil.UTC().Format("2006-01-02 15:04 MST")
+		case "off":
+			record.RemindersDisabled = true
+			responseText = "Status update reminders turned off by <@" + s.UserID + ">"
+		case "on":
+			record.RemindersDisabled = false
+			record.RemindersSnoozedUntil = time.Time{}
+			responseText = "Status update reminders turned on"
+		}
+	})
+	if err != nil {
+		msg := "ERROR!! Error updating the incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	response := SlashResponse{"in_channel", responseText}
+	slackCommandResponse(response, s)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Strategies to pick the responders of an incident triggered from pagerduty
+const (
+	responderStrategyOnCall       = "oncall"
+	responderStrategyOnCallLevel1 = "oncall-level-1"
+	responderStrategyAssignees    = "assignees"
+	responderStrategyTeam         = "team"
+)
+
+var defaultResponderStrategies = []string{responderStrategyAssignees, responderStrategyOnCallLevel1}
+
+// ******************************************************************************
+// Name				: getResponderConfigs
+// Description: Function to get the responder configuration of the affected
+// 							services, including the default responders
+// ******************************************************************************
+func getResponderConfigs(services []string) []ResponderConfig {
+	configs := []ResponderConfig{constants.Responders.Default}
+	for _, service := range services {
+		if config, ok := constants.Responders.Services[service]; ok {
+			configs = append(configs, config)
+		}
+	}
+	return configs
+}
+
+// ******************************************************************************
+// Name				: getPDResponders
+// Description: Function to get the pagerduty users responding for a responder
+// 							configuration
+// ******************************************************************************
+func getPDResponders(config ResponderConfig) []User {
+	var users []User
+	for _, teamID := range config.PagerDutyTeamIDs {
+		memberList := loadPDTeamMembers("https://api.pagerduty.com/teams/" + teamID + "/members")
+		for _, j := range memberList.Members {
+			users = append(users, j.User)
+		}
+	}
+	for _, policyID := range config.PagerDutyEscalationPolicyIDs {
+		users = append(users, getOnCallUsers(policyID, 1)...)
+	}
+	return users
+}
+
+// ******************************************************************************
+// Name				: getResponders
+// Description: Function to get the slack users to invite to the channel of an
+// 							incident. The user who declared the incident always comes
+// 							first. Responders that cannot be resolved are returned as
+// 							failures
+// ******************************************************************************
+func getResponders(userID string, services []string) ([]string, []InviteFailure) {
+	var userIDs []string
+	var failures []InviteFailure
+	seen := map[string]bool{}
+	addUser := func(id string) {
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		userIDs = append(userIDs, id)
+	}
+
+	addUser(userID)
+	for _, config := range getResponderConfigs(services) {
+		for _, id := range config.SlackUserIDs {
+			addUser(id)
+		}
+		for _, groupID := range config.SlackUserGroupIDs {
+			members, err := getUserGroupMembers(groupID)
+			if err != nil {
+				failures = append(failures, InviteFailure{"<!subteam^" + groupID + ">", "members could not be loaded (" + err.Error() + ")"})
+				continue
+			}
+			for _, id := range members {
+				addUser(id)
+			}
+		}
+		ids, pdFailures := resolveSlackUsers(getPDResponders(config))
+		for _, id := range ids {
+			addUser(id)
+		}
+		failures = append(failures, pdFailures...)
+	}
+	return userIDs, failures
+}
+
+// ******************************************************************************
+// Name				: inviteResponders
+// Description: Function to invite the responders of an incident to its channel
+// 							and report the ones that could not be invited
+// ******************************************************************************
+func inviteResponders(channelID string, userID string, services []string) {
+	userIDs, failures := getResponders(userID, services)
+	failures = append(failures, inviteUsersBestEffort(channelID, userIDs)...)
+	postInviteSummary(channelID, failures)
+}
+
+// ******************************************************************************
+// Name				: getPDIncidentResponders
+// Description: Function to get the pagerduty users to invite to the channel of
+// 							an incident triggered from pagerduty, following the
+// 							strategies in pagerduty.responder_strategies
+// ******************************************************************************
+func getPDIncidentResponders(incident Incident) []User {
+	strategies := constants.PagerDuty.ResponderStrategies
+	if len(strategies) == 0 {
+		strategies = defaultResponderStrategies
+	}
+
+	var users []User
+	seen := map[string]bool{}
+	addUsers := func(list []User) {
+		for _, user := range list {
+			if user.ID == "" || seen[user.ID] {
+				continue
+			}
+			seen[user.ID] = true
+			users = append(users, user)
+		}
+	}
+
+	for _, strategy := range strategies {
+		switch strategy {
+		case responderStrategyOnCall:
+			addUsers(getOnCallUsers(incident.EscalationPolicy.ID, 0))
+		case responderStrategyOnCallLevel1:
+			addUsers(getOnCallUsers(incident.EscalationPolicy.ID, 1))
+		case responderStrategyAssignees:
+			if len(incident.Assignments) > 0 {
+				addUsers(getPDIncidentAssignees(incident))
+			}
+		case responderStrategyTeam:
+			for _, team := range incident.Teams {
+				for _, j := range loadPDTeamMembers(team.Self + "/members").Members {
+					addUsers([]User{j.User})
+				}
+			}
+		default:
+			log.Warn("Unknown responder strategy: ", strategy)
+		}
+	}
+	return users
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultSchedulerInterval = time.Minute
+
+// scheduledJobs are run on every tick of the scheduler. Jobs keep their state in
+// the incident store so that they pick up where they left off after a restart
+var scheduledJobs = []func(now time.Time){
+	archiveResolvedChannels,
+	remindStatusUpdates,
+	digestOverdueActions,
+}
+
+// ******************************************************************************
+// Name				: schedulerInitializer
+// Description: Function to start running the scheduled jobs in the background
+// ******************************************************************************
+func schedulerInitializer() {
+	interval := defaultSchedulerInterval
+	if constants.Scheduler.Interval != "" {
+		configured, err := time.ParseDuration(constants.Scheduler.Interval)
+		if err != nil || configured <= 0 {
+			log.Error("schedulerInitializer Invalid Interval: ", constants.Scheduler.Interval)
+		} else {
+			interval = configured
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		for now := range ticker.C {
+			runScheduledJobs(now)
+		}
+	}()
+}
+
+// ******************************************************************************
+// Name				: runScheduledJobs
+// Description: Function to run all scheduled jobs one after the other. A job
+// 							that panics does not stop the scheduler
+// ******************************************************************************
+func runScheduledJobs(now time.Time) {
+	for _, job := range scheduledJobs {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Error("runScheduledJobs Error: ", err)
+				}
+			}()
+			job(now)
+		}()
+	}
+}
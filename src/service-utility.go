@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"io/ioutil"
-	"net/http"
 	"strings"
 	"sync"
 
@@ -24,6 +23,11 @@ type Constants struct {
 	PagerDuty          PagerDutyConstants          `json:"pagerduty"`
 	JIRA               JIRAConstants               `json:"jira"`
 	StatusPage         StatusPageConstants         `json:"statuspage"`
+	IncidentStore      IncidentStoreConstants      `json:"incident_store"`
+	Responders         RespondersConstants         `json:"responders"`
+	Scheduler          SchedulerConstants          `json:"scheduler"`
+	Reminders          RemindersConstants          `json:"reminders"`
+	RelatedIncidents   RelatedIncidentsConstants   `json:"related_incidents"`
 	ValidationMessages ValidationMessagesConstants `json:"validation_messages"`
 }
 
@@ -40,25 +44,142 @@ type ValidationMessagesConstants struct {
 	AllowedStatusPageStatus        string `json:"allowed_statuspage_status"`
 	AllowedJiraStatus              string `json:"allowed_jira_status"`
 	TryAgain                       string `json:"try_again"`
+	InvalidTemplate                string `json:"invalid_template"`
+	InvalidPage                    string `json:"invalid_page"`
+	InvalidVisibility              string `json:"invalid_visibility"`
+	VisibilityCommandFormat        string `json:"visibility_command_format"`
+	ApproveCommandFormat           string `json:"approve_command_format"`
+	SeverityCommandFormat          string `json:"severity_command_format"`
+	KeepOpenCommandFormat          string `json:"keep_open_command_format"`
+	RemindersCommandFormat         string `json:"reminders_command_format"`
+	MirrorCommandFormat            string `json:"mirror_command_format"`
+	ActionCommandFormat            string `json:"action_command_format"`
+	MergeCommandFormat             string `json:"merge_command_format"`
+	IncidentNotFound               string `json:"incident_not_found"`
+	IncidentAccessDenied           string `json:"incident_access_denied"`
 }
 
 type StatusPageConstants struct {
+	PageID               string              `json:"page_id"`
+	DeliverNotifications bool                `json:"deliver_notifications"`
+	TemplatesDir         string              `json:"templates_dir"`
+	Pages                []StatusPagePage    `json:"pages"`
+	ServicePages         map[string][]string `json:"service_pages"`
+}
+
+type StatusPagePage struct {
+	Name                 string `json:"name"`
 	PageID               string `json:"page_id"`
 	DeliverNotifications bool   `json:"deliver_notifications"`
 }
 
+type SchedulerConstants struct {
+	Interval string `json:"interval"`
+}
+
+// RemindersConstants configures how often incident channels are reminded to
+// post a status update, per severity. Default applies to other severities
+type RemindersConstants struct {
+	Default   string            `json:"default"`
+	Intervals map[string]string `json:"intervals"`
+}
+
+// RelatedIncidentsConstants configures how far back and how related incidents
+// are looked up when an incident is created
+type RelatedIncidentsConstants struct {
+	Window   string `json:"window"`
+	LinkType string `json:"link_type"`
+	JQL      string `json:"jql"`
+}
+
+type IncidentStoreConstants struct {
+	Path string `json:"path"`
+}
+
 type SlackConstants struct {
-	NotificationChannelIDs string `json:"notification_channel_ids"`
+	NotificationChannelIDs         string              `json:"notification_channel_ids"`
+	SeverityNotificationChannelIDs map[string][]string `json:"severity_notification_channel_ids"`
+	CommsUserGroupID               string              `json:"comms_user_group_id"`
+	CommsUserIDs                   []string            `json:"comms_user_ids"`
+	EmailAliases                   map[string]string   `json:"email_aliases"`
+	Channels                       ChannelConstants    `json:"channels"`
+}
+
+type ChannelConstants struct {
+	NameTemplate         string            `json:"name_template"`
+	ServiceNameTemplates map[string]string `json:"service_name_templates"`
+	PrivateServices      []string          `json:"private_services"`
+	ArchiveAfter         string            `json:"archive_after"`
+	ArchiveWarning       string            `json:"archive_warning"`
+}
+
+// RespondersConstants configures who is invited to the channel of an incident
+// declared from Slack. Services maps a service or component to its responders
+// and Default applies to every incident
+type RespondersConstants struct {
+	Default  ResponderConfig            `json:"default"`
+	Services map[string]ResponderConfig `json:"services"`
+}
+
+type ResponderConfig struct {
+	SlackUserGroupIDs            []string `json:"slack_user_group_ids"`
+	SlackUserIDs                 []string `json:"slack_user_ids"`
+	PagerDutyTeamIDs             []string `json:"pagerduty_team_ids"`
+	PagerDutyEscalationPolicyIDs []string `json:"pagerduty_escalation_policy_ids"`
 }
 
 type PagerDutyConstants struct {
-	Endpoint string `json:"endpoint"`
+	Endpoint            string   `json:"endpoint"`
+	ResponderStrategies []string `json:"responder_strategies"`
 }
 
 type JIRAConstants struct {
-	Endpoint    string `json:"endpoint"`
-	IssueTypeID string `json:"issue_type_id"`
-	ProjectID   string `json:"project_id"`
+	Auth             string                `json:"auth"`
+	TranscriptFormat string                `json:"transcript_format"`
+	MergeLinkType    string                `json:"merge_link_type"`
+	Endpoint         string                `json:"endpoint"`
+	IssueTypeID      string                `json:"issue_type_id"`
+	ProjectID        string                `json:"project_id"`
+	Mirror           MirrorConstants       `json:"mirror"`
+	Fields           JiraFieldsConstants   `json:"fields"`
+	Users            JiraUsersConstants    `json:"users"`
+	Workflow         JiraWorkflowConstants `json:"workflow"`
+	Actions          JiraActionsConstants  `json:"actions"`
+}
+
+type JiraActionsConstants struct {
+	ProjectID        string   `json:"project_id"`
+	IssueTypeID      string   `json:"issue_type_id"`
+	LinkType         string   `json:"link_type"`
+	DigestChannelIDs []string `json:"digest_channel_ids"`
+	DigestInterval   string   `json:"digest_interval"`
+}
+
+type JiraWorkflowConstants struct {
+	Default  map[string]JiraTransitionConfig            `json:"default"`
+	Projects map[string]map[string]JiraTransitionConfig `json:"projects"`
+}
+
+type JiraTransitionConfig struct {
+	Transition string `json:"transition"`
+	Resolution string `json:"resolution"`
+}
+
+type JiraUsersConstants struct {
+	DomainAliases map[string]string `json:"domain_aliases"`
+	EmailAliases  map[string]string `json:"email_aliases"`
+}
+
+type JiraFieldsConstants struct {
+	DescriptionTemplate string                 `json:"description_template"`
+	Priorities          map[string]string      `json:"priorities"`
+	Labels              []string               `json:"labels"`
+	CustomFields        map[string]interface{} `json:"custom_fields"`
+}
+
+type MirrorConstants struct {
+	Mode     string `json:"mode"`
+	Reaction string `json:"reaction"`
 }
 
 var helpMessage string
@@ -73,6 +194,7 @@ type PDService struct {
 type SPComponent struct {
 	Name string `json:"name,omitempty"`
 	ID   string `json:"id,omitempty"`
+	Page string `json:"page,omitempty"`
 }
 
 type JiraComponent struct {
@@ -99,6 +221,18 @@ type StatusPageMappings struct {
 	StatusPageMappings []StatusPageMap `json:"statuspage_mappings,omitempty"`
 }
 
+// IncidentDeclaration describes a new incident declared from Slack
+type IncidentDeclaration struct {
+	Title            string
+	Description      string
+	Severity         string
+	Services         []string
+	Visibility       string
+	CreateStatusPage bool
+	TeamID           string
+	Security         bool
+}
+
 // ******************************************************************************
 // Name				: helpMessageInitializer
 // Description: Function to load custom help message from config file
@@ -134,6 +268,9 @@ func statusPageMappingsInitializer() {
 			log.Error("statusPageMappingsInitializer Error: ", err)
 		}
 		json.Unmarshal(data, &statusPageMappings)
+		if statusPageMappings == nil {
+			statusPageMappings = &StatusPageMappings{}
+		}
 	}
 	for _, j := range statusPageMappings.StatusPageMappings {
 		log.Debug(j.Service, " - ", j.SPComponent.Name)
@@ -172,18 +309,15 @@ func getSPMapping(serviceID string) []SPComponent {
 	return nil
 }
 
-func getAffectedSPComponents(serviceID string) []string {
-	var componentIDs []string
-	components := getSPMapping(serviceID)
-	for _, j := range components {
-		componentIDs = append(componentIDs, j.ID)
-	}
-	return componentIDs
-
+func getAffectedSPComponents(serviceID string) []SPComponent {
+	return getSPMapping(serviceID)
 }
 
 func getAffectedJiraComponents(serviceID string) []string {
 	mapping := readConfig(serviceID)
+	if mapping == nil {
+		return nil
+	}
 	var componentIDs []string
 	for _, j := range mapping.JiraComponents {
 		componentIDs = append(componentIDs, j.ID)
@@ -196,17 +330,38 @@ func parseAffectedComponents(affectedComponents string) []string {
 	components := strings.Trim(componentSlice[1], "[], ")
 	componentList := strings.Split(components, ",")
 	log.Debug(componentList)
-	var componentIDList []string
-
+	var services []string
 	for _, comp := range componentList {
+		comp = strings.Trim(comp, " ")
+		if comp != "" {
+			services = append(services, comp)
+		}
+	}
+	return services
+}
+
+func getServiceSPComponents(services []string) []SPComponent {
+	statusPageMappingsInitializer()
+	var componentList []SPComponent
+
+	for _, service := range services {
+		mapped := false
 		for _, j := range statusPageMappings.StatusPageMappings {
-			if j.Service == strings.Trim(comp, " ") {
-				componentIDList = append(componentIDList, j.SPComponent.ID)
+			if j.Service == service {
+				componentList = append(componentList, j.SPComponent)
+				mapped = true
+			}
+		}
+		// Components can also be referenced directly as <page>/<component_id>
+		if !mapped && strings.Contains(service, "/") {
+			reference := strings.SplitN(service, "/", 2)
+			if page, err := getStatusPage(reference[0]); err == nil && reference[1] != "" {
+				componentList = append(componentList, SPComponent{ID: reference[1], Page: page.Name})
 			}
 		}
 	}
-	log.Debug("componentIDList: ", componentIDList)
-	return componentIDList
+	log.Debug("componentList: ", componentList)
+	return componentList
 }
 
 func prepareSlackChannelPurpose(incidentID string, statusPageLink string, jiraURL string) string {
@@ -217,11 +372,29 @@ func prepareSlackChannelPurpose(incidentID string, statusPageLink string, jiraUR
 	return purpose
 }
 
-func processSlackPurpose(w http.ResponseWriter, s slack.SlashCommand) (statusPageLInk string, jiraURL string, err error) {
+// ******************************************************************************
+// Name				: getCommandIncidentLinks
+// Description: Function to get the StatusPage link and JIRA link of the incident
+// 							a command is used for from the incident store. Channels of
+// 							incidents created before the store are read from their purpose
+// ******************************************************************************
+func getCommandIncidentLinks(s slack.SlashCommand) (statusPageLink string, jiraURL string, err error) {
+	record, err := incidentStore.FindByChannel(s.ChannelID)
+	if err != nil || record.JiraKey == "" {
+		return processSlackPurpose(s)
+	}
+	if len(record.StatusPageIncidents) > 0 {
+		ref := record.StatusPageIncidents[0]
+		statusPageLink = getStatusPageLink(ref.PageID, ref.IncidentID)
+	}
+	return statusPageLink, constants.JIRA.Endpoint + "/browse/" + record.JiraKey, nil
+}
+
+func processSlackPurpose(s slack.SlashCommand) (statusPageLInk string, jiraURL string, err error) {
 	var statusPageLink, jiraLink string
 	purpose, err := getChannelPurpose(s.ChannelID)
 	if err != nil {
-		msg := "ERROR!! Error reading the purpose of the channel: " + err.Error() + "\n" + "Please make sure the about info of the channel is unchanged and you are using the command from incident channel."
+		msg := "ERROR!! Error reading the purpose of the channel: " + err.Error() + "\n" + "Please make sure the about info of the channel is unchanged, or name the incident with inc=<incident>."
 		response := SlashResponse{"ephemeral", msg}
 		slackCommandResponse(response, s)
 		err = errors.New("InternalError")
@@ -229,26 +402,22 @@ func processSlackPurpose(w http.ResponseWriter, s slack.SlashCommand) (statusPag
 	}
 	description := strings.Split(purpose, "\n\n")
 	if isSlackDescriptionInvalid(description) {
-		msg := "ERROR!! Error parsing the purpose of the channel. \n" + "Please make sure the about info of the channel is unchanged and you are using the command from incident channel."
+		msg := "ERROR!! Error parsing the purpose of the channel. \n" + "Please make sure the about info of the channel is unchanged, or name the incident with inc=<incident>."
 		response := SlashResponse{"ephemeral", msg}
 		slackCommandResponse(response, s)
 		err = errors.New("InternalError")
 		return statusPageLInk, jiraLink, err
 	}
 	statusPageID := strings.TrimSpace(strings.Split(description[0], ":=")[1])
-	statusPageLink = "https://api.statuspage.io/v1/pages/" + constants.StatusPage.PageID + "/incidents/" + statusPageID
+	statusPageLink = getStatusPageLink(constants.StatusPage.PageID, statusPageID)
 	jiraURL = strings.TrimSpace(strings.Split(description[len(description)-1], "Jira Link :")[1])
 	return statusPageLink, jiraURL, err
 }
 
-func getChannelName(issueKey string) string {
-	return ("gl-" + strings.ToLower(issueKey))
-}
-
 func setJiraStatusForGenericComment(arguments []string) string {
 	var jiraStatus string = ""
-	if arguments[1] == "resolved" {
-		jiraStatus = "close"
+	if arguments[1] != "current" {
+		jiraStatus = arguments[1]
 	}
 	return jiraStatus
 }
@@ -257,8 +426,14 @@ func setJiraStatusForJiraComment(arguments []string) string {
 	var jiraStatus string = ""
 	if len(arguments) == 3 {
 		if arguments[1] == "resolved" {
-			jiraStatus = "close"
+			jiraStatus = "resolved"
 		}
 	}
 	return jiraStatus
 }
+
+func getJiraKeyFromURL(jiraURL string) string {
+	jiraURL = strings.Trim(jiraURL, "<> ")
+	urlSplit := strings.Split(jiraURL, "/")
+	return urlSplit[len(urlSplit)-1]
+}
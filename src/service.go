@@ -2,6 +2,7 @@ package main
 
 import (
 	"net/http"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/slack-go/slack"
@@ -13,42 +14,72 @@ import (
 // ******************************************************************************
 func pagerDutyService(payload Payload) {
 	mutex.Lock()
-	memberList := loadPDTeamMembers(payload.Messages[0].Incident.Teams[0].Self + "/members")
-	users := []User{}
-
-	for _, j := range memberList.Members {
-		var user User
-		user.ID = j.User.ID
-		user = getPDUser(constants.PagerDuty.Endpoint + user.ID)
-		users = append(users, user)
-	}
+	users := getPDIncidentResponders(payload.Messages[0].Incident)
 	incidentSummary := payload.Messages[0].Incident.Summary
-	issue, err := createJiraIssue(incidentSummary)
+	service := payload.Messages[0].Incident.Service
+	services := []string{service.ID, service.Name}
+	issue, err := createJiraIssue(JiraIssueData{
+		Title:        incidentSummary,
+		Description:  payload.Messages[0].Incident.Description,
+		Services:     services,
+		PagerDutyURL: payload.Messages[0].Incident.HTMLURL,
+	})
 	if err != nil {
 		log.Error("pagerDutyService JIRA Creation Error: ", err)
 	}
 	log.Info("JIRA issue created: ", issue.Key)
 
-	channelName := getChannelName(issue.Key)
-	channel, err := createNewChannel(channelName, users)
+	channelName := getChannelName(ChannelNameData{
+		JiraKey:  issue.Key,
+		Title:    payload.Messages[0].Incident.Title,
+		Services: []string{service.Name, service.ID},
+	})
+	channel, err := createNewChannel(channelName, isPrivateIncident(false, services), users)
 	if err != nil {
 		log.Error("pagerDutyService Slack Channel Creation Error: ", err)
 	}
 	log.Info("Slack Channel Created: ", channel.Name)
 
-	componentList := getAffectedSPComponents(payload.Messages[0].Incident.Service.ID)
+	componentList := getAffectedSPComponents(service.ID)
+	routes := getStatusPageRoutes(services, componentList)
 	var severity string
-	statusPageIncident, err := createStatusPageIncident(payload.Messages[0].Incident.Title, payload.Messages[0].Incident.Description, severity, componentList)
+	statusPageIncidents, err := createStatusPageIncidents(payload.Messages[0].Incident.Title, payload.Messages[0].Incident.Description, severity, routes)
 	if err != nil {
 		log.Error("pagerDutyService StatusPage Incident Creation Error: ", err)
 	}
-	incidentID := "incidentID : " + statusPageIncident.ID
+	statusPageIncident := StatusPageIncidentRef{}
+	if len(statusPageIncidents) > 0 {
+		statusPageIncident = statusPageIncidents[0]
+	}
+	incidentID := "incidentID : " + statusPageIncident.IncidentID
 	statusPageLink := "StatusPage link : " + statusPageIncident.Shortlink
 	pagerDutyLink := "PagerDuty link : " + payload.Messages[0].Incident.HTMLURL
 	jiraLink := "Jira link : " + (constants.JIRA.Endpoint + "/browse/") + issue.Key
 	purpose := incidentID + "\n\n" + statusPageLink + "\n\n" + pagerDutyLink + "\n\n" + jiraLink
 	setChannelPurpose(channel.ID, purpose)
-	postMessageToSlackChannel(channel.ID, payload.Messages[0].Incident.Title)
+
+	record := IncidentRecord{
+		Title:               payload.Messages[0].Incident.Title,
+		Severity:            severity,
+		Status:              "investigating",
+		ChannelID:           channel.ID,
+		PrivateChannel:      isPrivateIncident(false, services),
+		Services:            []string{service.Name},
+		JiraKey:             issue.Key,
+		PagerDutyURL:        payload.Messages[0].Incident.HTMLURL,
+		PDServiceID:         service.ID,
+		SPComponentIDs:      getSPComponentIDs(componentList),
+		StatusPageIncidents: statusPageIncidents,
+	}
+	stored, err := incidentStore.Add(record)
+	if err != nil {
+		log.Error("pagerDutyService Incident Store Error: ", err)
+	} else {
+		record = stored
+	}
+	setupIncidentChannel(record)
+	go linkRelatedIncidents(record)
+	postIncidentAlert(record)
 	mutex.Unlock()
 }
 
@@ -59,52 +90,80 @@ func pagerDutyService(payload Payload) {
 func slashCommandService(w http.ResponseWriter, s slack.SlashCommand, arguments []string) {
 	switch arguments[0] {
 	case "comment":
-		statusPageLink, jiraURL, err := processSlackPurpose(w, s)
-		if err != nil {
-			return
-		}
-		err = updateStatePage(arguments[1:], statusPageLink, s)
+		commentCommandService(s, arguments)
+	case "comment-jira":
+		_, jiraURL, err := getCommandIncidentLinks(s)
 		if err != nil {
 			return
 		}
-		jiraStatus := setJiraStatusForGenericComment(arguments)
+		jiraStatus := setJiraStatusForJiraComment(arguments)
 		err = addJiraComment(jiraURL, s.UserName, arguments, jiraStatus, s)
 		if err != nil {
 			return
 		}
-		response := SlashResponse{"in_channel", "Comment added to StatusPage and JIRA"}
+		if jiraStatus == "resolved" {
+			trackIncidentStatus(s.ChannelID, "resolved")
+		}
+		response := SlashResponse{"in_channel", "Comment added to JIRA"}
 		slackCommandResponse(response, s)
-	case "comment-jira":
-		_, jiraURL, err := processSlackPurpose(w, s)
+	case "comment-statuspage":
+		statusPageLink, jiraURL, err := getCommandIncidentLinks(s)
 		if err != nil {
 			return
 		}
-		jiraStatus := setJiraStatusForJiraComment(arguments)
-		err = addJiraComment(jiraURL, s.UserName, arguments, jiraStatus, s)
+		statusPageLinks, err := getStatusPageLinksForComment(arguments, statusPageLink, s)
 		if err != nil {
 			return
 		}
-		response := SlashResponse{"in_channel", "Comment added to JIRA"}
-		slackCommandResponse(response, s)
-	case "comment-statuspage":
-		statusPageLink, _, err := processSlackPurpose(w, s)
+		err = renderStatusPageMessage(arguments, statusPageLinks, s)
 		if err != nil {
 			return
 		}
-		err = updateStatePage(arguments[1:], statusPageLink, s)
+		note, err := publishStatusPageComment(arguments[1:], statusPageLinks, s)
 		if err != nil {
 			return
 		}
-		response := SlashResponse{"in_channel", "Comment added to StatusPage"}
+		transitionJiraIssueForCommand(getJiraKeyFromURL(jiraURL), setJiraStatusForGenericComment(arguments), s)
+		trackIncidentStatus(s.ChannelID, arguments[1])
+		responseText := "Comment added to StatusPage"
+		if note != "" {
+			responseText = note
+		}
+		response := SlashResponse{"in_channel", responseText}
 		slackCommandResponse(response, s)
 	case "issue":
 		go issueCommandService(s, arguments)
 	case "statuspage-incident":
-		_, jiraURL, err := processSlackPurpose(w, s)
+		_, jiraURL, err := getCommandIncidentLinks(s)
 		if err != nil {
 			return
 		}
 		go statuspageCommandService(s, arguments, jiraURL)
+	case "declare":
+		err := openDeclareModal(s.TriggerID, DeclareModalMetadata{ChannelID: s.ChannelID, ResponseURL: s.ResponseURL})
+		if err != nil {
+			msg := "ERROR!! Error opening the declare incident form: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+			response := SlashResponse{"ephemeral", msg}
+			slackCommandResponse(response, s)
+		}
+	case "severity":
+		severityCommandService(s, arguments)
+	case "keep-open":
+		keepOpenCommandService(s, arguments)
+	case "reminders":
+		remindersCommandService(s, arguments)
+	case "action":
+		go actionCommandService(s, arguments)
+	case "actions":
+		go actionsCommandService(s, arguments)
+	case "merge":
+		go mergeCommandService(s, arguments)
+	case "mirror":
+		mirrorCommandService(s, arguments)
+	case "visibility":
+		visibilityCommandService(s, arguments)
+	case "approve", "reject":
+		approveCommandService(s, arguments)
 	case "help":
 		slashHelpResponse(s)
 	default:
@@ -113,43 +172,139 @@ func slashCommandService(w http.ResponseWriter, s slack.SlashCommand, arguments
 	}
 }
 
+// ******************************************************************************
+// Name				: commentCommandService
+// Description: Function to add the same comment to JIRA and StatusPage and
+// 							update the status of the incident
+// ******************************************************************************
+func commentCommandService(s slack.SlashCommand, arguments []string) {
+	statusPageLink, jiraURL, err := getCommandIncidentLinks(s)
+	if err != nil {
+		return
+	}
+	statusPageLinks, err := getStatusPageLinksForComment(arguments, statusPageLink, s)
+	if err != nil {
+		return
+	}
+	err = renderStatusPageMessage(arguments, statusPageLinks, s)
+	if err != nil {
+		return
+	}
+	note, err := publishStatusPageComment(arguments[1:], statusPageLinks, s)
+	if err != nil {
+		return
+	}
+	jiraStatus := setJiraStatusForGenericComment(arguments)
+	err = addJiraComment(jiraURL, s.UserName, arguments, jiraStatus, s)
+	if err != nil {
+		return
+	}
+	trackIncidentStatus(s.ChannelID, arguments[1])
+	responseText := "Comment added to StatusPage and JIRA"
+	if note != "" {
+		responseText = "Comment added to JIRA. " + note
+	}
+	response := SlashResponse{"in_channel", responseText}
+	slackCommandResponse(response, s)
+}
+
 // ******************************************************************************
 // Name				: issueCommandService
 // Description: Function to create new Slack channel, StatusPage and JIRA ticket
 //              for the incident
 // ******************************************************************************
 func issueCommandService(s slack.SlashCommand, arguments []string) {
+	severity, services := parseSubCommandArguments(arguments)
+	_, visibility := splitVisibilityArgument(arguments)
+	if visibility == "" {
+		visibility = visibilityPublic
+	}
+	declaration := IncidentDeclaration{
+		Title:            arguments[1],
+		Severity:         severity,
+		Services:         services,
+		Visibility:       visibility,
+		CreateStatusPage: true,
+	}
+	declareIncident(s, declaration)
+}
+
+// ******************************************************************************
+// Name				: declareIncident
+// Description: Function to create the JIRA ticket, Slack channel and StatusPage
+// 							incident for a new incident declared from Slack
+// ******************************************************************************
+func declareIncident(s slack.SlashCommand, declaration IncidentDeclaration) {
 	mutex.Lock()
-	issueTitle := arguments[1]
-	severity, componentIDList := parseSubCommandArguments(arguments)
+	issueTitle := declaration.Title
+	severity := declaration.Severity
+	visibility := declaration.Visibility
 
-	issueKey, err := createJIRAIssue(issueTitle, s)
+	issueKey, err := createJIRAIssue(declaration, s)
 	if err != nil {
 		mutex.Unlock()
 		return
 	}
 
-	channelID, err := createSlackChannel(issueKey, s)
+	channelID, err := createSlackChannel(issueKey, declaration, s)
 	if err != nil {
 		mutex.Unlock()
 		return
 	}
 
-	statusPageIncident, err := createStatusPage(s, issueTitle, severity, componentIDList)
-	if err != nil {
-		mutex.Unlock()
-		return
+	// Invite the user who declared the incident and the configured responders
+	inviteResponders(channelID, s.UserID, declaration.Services)
+
+	// StatusPage incidents are only created right away for public incidents
+	createStatusPageIncident := declaration.CreateStatusPage && visibility == visibilityPublic
+	statusPageIncidents := []StatusPageIncidentRef{{}}
+	if createStatusPageIncident {
+		statusPageIncidents, err = createStatusPage(s, issueTitle, declaration.Description, severity, declaration.Services)
+		if err != nil {
+			mutex.Unlock()
+			return
+		}
 	}
 
 	jiraURL := constants.JIRA.Endpoint + "/browse/" + issueKey
-	err = setSlackChannelPurpose(s, statusPageIncident, jiraURL, channelID)
+	err = setSlackChannelPurpose(s, statusPageIncidents[0], jiraURL, channelID)
 	if err != nil {
 		mutex.Unlock()
 		return
 	}
+	if !createStatusPageIncident {
+		statusPageIncidents = nil
+	}
+
+	record := IncidentRecord{
+		Title:               issueTitle,
+		Severity:            severity,
+		Status:              "investigating",
+		ChannelID:           channelID,
+		PrivateChannel:      isPrivateIncident(declaration.Security, declaration.Services),
+		Services:            declaration.Services,
+		SPComponentIDs:      getSPComponentIDs(getServiceSPComponents(declaration.Services)),
+		CommanderID:         s.UserID,
+		JiraKey:             issueKey,
+		StatusPageIncidents: statusPageIncidents,
+		Visibility:          visibility,
+	}
+	stored, err := incidentStore.Add(record)
+	if err != nil {
+		log.Error("declareIncident Incident Store Error: ", err)
+	} else {
+		record = stored
+	}
+	setupIncidentChannel(record)
+	go linkRelatedIncidents(record)
+
+	// Page the team the incident was assigned to
+	if declaration.TeamID != "" {
+		postMessageToChannel(channelID, "<!subteam^"+declaration.TeamID+"> this incident has been assigned to your team")
+	}
 
 	// Post message to other relevant channels
-	postMessageToSlackChannel(channelID, s.Text)
+	postIncidentAlert(record)
 
 	// Respond back to the user who executed the command
 	responseText := "Success! All relevant members are requested to join the group <#" + channelID + ">"
@@ -164,25 +319,183 @@ func issueCommandService(s slack.SlashCommand, arguments []string) {
 // ******************************************************************************
 func statuspageCommandService(s slack.SlashCommand, arguments []string, jiraURL string) {
 	mutex.Lock()
+	if record, err := incidentStore.FindByChannel(s.ChannelID); err == nil {
+		visibility := getIncidentVisibility(record)
+		if visibility == visibilityInternal || (visibility == visibilityDelayedPublic && !canPublishStatusPage(s.UserID)) {
+			msg := "ERROR!! The incident is " + visibility + ". Only " + getCommsMention() + " can publish it on StatusPage."
+			response := SlashResponse{"ephemeral", msg}
+			slackCommandResponse(response, s)
+			mutex.Unlock()
+			return
+		}
+	}
 	issueTitle := arguments[1]
-	severity, componentIDList := parseSubCommandArguments(arguments)
+	severity, services := parseSubCommandArguments(arguments)
 
 	// Status Page Creation
-	statusPageIncident, err := createStatusPage(s, issueTitle, severity, componentIDList)
+	statusPageIncidents, err := createStatusPage(s, issueTitle, "", severity, services)
 	if err != nil {
 		mutex.Unlock()
 		return
 	}
 
 	// To set Slack Channel Description
-	err = setSlackChannelPurpose(s, statusPageIncident, jiraURL, "")
+	err = setSlackChannelPurpose(s, statusPageIncidents[0], jiraURL, "")
 	if err != nil {
 		mutex.Unlock()
 		return
 	}
 
+	// Track the StatusPage incidents for the incident of this channel
+	_, err = updateIncident(getIncidentIDForChannel(s.ChannelID, issueTitle, jiraURL), func(record *IncidentRecord) {
+		record.Severity = severity
+		record.StatusPageIncidents = append(record.StatusPageIncidents, statusPageIncidents...)
+	})
+	if err != nil {
+		log.Error("statuspageCommandService Incident Store Error: ", err)
+	}
+
 	responseText := "Success! Statuspage Incident created!!"
 	response := SlashResponse{"in_channel", responseText}
 	slackCommandResponse(response, s)
 	mutex.Unlock()
 }
+
+// ******************************************************************************
+// Name				: visibilityCommandService
+// Description: Function to change the visibility mode of the incident
+// ******************************************************************************
+func visibilityCommandService(s slack.SlashCommand, arguments []string) {
+	record, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	visibility := arguments[1]
+	if visibility != visibilityInternal && getIncidentVisibility(record) != visibilityPublic && !canPublishStatusPage(s.UserID) {
+		msg := "ERROR!! Only " + getCommsMention() + " can make the incident " + visibility + "."
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	record, err = updateIncident(record.ID, func(record *IncidentRecord) {
+		record.Visibility = visibility
+		// Internal incidents never publish, so queued updates are dropped
+		if visibility == visibilityInternal {
+			record.PendingUpdates = nil
+		}
+	})
+	if err != nil {
+		msg := "ERROR!! Error updating the incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	// Incidents made public are created on StatusPage if they are not yet
+	if visibility == visibilityPublic {
+		_, err = publishIncidentOnStatusPage(record)
+		if err != nil {
+			msg := "ERROR!! Error creating StatusPage Incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+			response := SlashResponse{"ephemeral", msg}
+			slackCommandResponse(response, s)
+		}
+	}
+	response := SlashResponse{"in_channel", "Incident visibility set to " + visibility}
+	slackCommandResponse(response, s)
+}
+
+// ******************************************************************************
+// Name				: approveCommandService
+// Description: Function to publish or discard a pending StatusPage update
+// ******************************************************************************
+func approveCommandService(s slack.SlashCommand, arguments []string) {
+	record, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	if !canPublishStatusPage(s.UserID) {
+		msg := "ERROR!! Only " + getCommsMention() + " can " + arguments[0] + " StatusPage updates."
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	// Internal incidents are never published, even with updates queued
+	// before the incident was made internal
+	if arguments[0] == "approve" && getIncidentVisibility(record) == visibilityInternal {
+		msg := "ERROR!! StatusPage updates of internal-only incidents cannot be approved. Make the incident delayed-public or public first."
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	update, err := takePendingUpdate(record.ID, arguments[1])
+	if err != nil {
+		msg := "ERROR!! " + err.Error()
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	if arguments[0] == "reject" {
+		response := SlashResponse{"in_channel", "StatusPage update #" + strconv.Itoa(update.ID) + " rejected by <@" + s.UserID + ">"}
+		slackCommandResponse(response, s)
+		return
+	}
+	failed, err := publishPendingUpdate(record, update)
+	if err != nil {
+		// Keep the update pending for the pages it was not posted to, so that
+		// it can be approved again
+		if len(failed) > 0 {
+			update.Links = failed
+		}
+		incidentStore.Update(record.ID, func(record *IncidentRecord) {
+			record.PendingUpdates = append(record.PendingUpdates, update)
+		})
+		msg := "ERROR!! Error updating StatusPage Incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	postStakeholderDigest(record, update.Status, update.Body)
+	response := SlashResponse{"in_channel", "StatusPage update #" + strconv.Itoa(update.ID) + " approved by <@" + s.UserID + "> and published"}
+	slackCommandResponse(response, s)
+}
+
+// ******************************************************************************
+// Name				: severityCommandService
+// Description: Function to change the severity of the incident and of its
+// 							StatusPage incidents
+// ******************************************************************************
+func severityCommandService(s slack.SlashCommand, arguments []string) {
+	record, err := getIncidentForCommand(s)
+	if err != nil {
+		return
+	}
+	severity := arguments[1]
+	responseText := "Incident severity set to " + severity + " by <@" + s.UserID + ">"
+	if getIncidentVisibility(record) == visibilityPublic {
+		for _, ref := range record.StatusPageIncidents {
+			_, err = updateStatusPageImpact(getStatusPageLink(ref.PageID, ref.IncidentID), severity)
+			if err != nil {
+				msg := "ERROR!! Error updating StatusPage Incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+				response := SlashResponse{"ephemeral", msg}
+				slackCommandResponse(response, s)
+				return
+			}
+		}
+	} else if len(record.StatusPageIncidents) > 0 {
+		responseText += ". The StatusPage impact was not changed as the incident is " + getIncidentVisibility(record)
+	}
+	err = updateIncidentForChannel(record.ChannelID, func(record *IncidentRecord) {
+		record.Severity = severity
+	})
+	if err != nil {
+		msg := "ERROR!! Error updating the incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return
+	}
+	// Announce the incident in the channels of its new severity
+	if record, err = incidentStore.FindByID(record.ID); err == nil {
+		postIncidentAlert(record)
+	}
+	response := SlashResponse{"in_channel", responseText}
+	slackCommandResponse(response, s)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// Action ids of the buttons on the App Home tab
+const (
+	homeDeclareAction     = "home_declare_incident"
+	homeOpenChannelAction = "home_open_channel"
+)
+
+// Resolved incidents are shown on the App Home tab for a week
+const recentResolutionsWindow = 7 * 24 * time.Hour
+
+const maxRecentResolutions = 10
+
+// At most this many incidents are listed per section, so that the tab stays
+// below the 100 blocks Slack allows in a view
+const maxHomeIncidents = 20
+
+// Incident changes are collected for this long before the App Home tabs are
+// refreshed, so that busy incidents do not hit the views.publish rate limit
+const homeRefreshDelay = 30 * time.Second
+
+var homeRefresh struct {
+	sync.Mutex
+	timer   *time.Timer
+	running sync.Mutex
+}
+
+// ******************************************************************************
+// Name				: formatIncidentAge
+// Description: Function to show how long ago an incident started, e.g. 2h35m
+// ******************************************************************************
+func formatIncidentAge(record IncidentRecord, now time.Time) string {
+	age := now.Sub(record.CreatedAt).Round(time.Minute)
+	if age < time.Minute {
+		return "just now"
+	}
+	text := age.String()
+	return text[:len(text)-2]
+}
+
+// ******************************************************************************
+// Name				: getIncidentHomeBlock
+// Description: Function to build the line of an incident on the App Home tab
+// ******************************************************************************
+func getIncidentHomeBlock(record IncidentRecord, details string) slack.Block {
+	text := "*" + record.Title + "*\n" + details
+	button := slack.NewButtonBlockElement(homeOpenChannelAction, strconv.Itoa(record.ID), slack.NewTextBlockObject(slack.PlainTextType, "Open channel", false, false))
+	button.URL = "https://slack.com/app_redirect?channel=" + record.ChannelID
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, slack.NewAccessory(button))
+}
+
+// ******************************************************************************
+// Name				: getOpenIncidentsURL
+// Description: Function to get the JIRA search listing all unresolved incidents,
+// 							linked from the App Home tab when not all incidents fit
+// ******************************************************************************
+func getOpenIncidentsURL() string {
+	jql := "project = " + constants.JIRA.ProjectID + " AND resolution = Unresolved ORDER BY created DESC"
+	return constants.JIRA.Endpoint + "/issues/?jql=" + url.QueryEscape(jql)
+}
+
+// ******************************************************************************
+// Name				: getHomeBlocks
+// Description: Function to build the App Home tab of a user: their open
+// 							incidents, all active incidents and recent resolutions
+// ******************************************************************************
+func getHomeBlocks(userID string, now time.Time) []slack.Block {
+	active := incidentStore.List(func(record *IncidentRecord) bool {
+		return record.Status != "resolved" && record.ArchivedAt.IsZero() && !record.PrivateChannel
+	})
+	resolved := incidentStore.List(func(record *IncidentRecord) bool {
+		return record.Status == "resolved" && now.Sub(record.ResolvedAt) < recentResolutionsWindow && !record.PrivateChannel
+	})
+	sort.Slice(active, func(i, j int) bool { return active[i].CreatedAt.After(active[j].CreatedAt) })
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].ResolvedAt.After(resolved[j].ResolvedAt) })
+
+	declareButton := slack.NewButtonBlockElement(homeDeclareAction, "", slack.NewTextBlockObject(slack.PlainTextType, "Declare incident", false, false)).WithStyle(slack.StyleDanger)
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Falcon", false, false)),
+		slack.NewActionBlock("home_actions", declareButton),
+	}
+
+	addSection := func(title string, records []IncidentRecord, details func(record IncidentRecord) string) {
+		blocks = append(blocks, slack.NewDividerBlock(), slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "*"+title+"*", false, false), nil, nil))
+		if len(records) == 0 {
+			blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, "Nothing here", false, false)))
+		}
+		more := len(records) - maxHomeIncidents
+		if more > 0 {
+			records = records[:maxHomeIncidents]
+		}
+		for _, record := range records {
+			blocks = append(blocks, getIncidentHomeBlock(record, details(record)))
+		}
+		if more > 0 {
+			text := "<" + getOpenIncidentsURL() + "|" + strconv.Itoa(more) + " more…>"
+			blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, text, false, false)))
+		}
+	}
+	activeDetails := func(record IncidentRecord) string {
+		return "Severity: " + valueOrNone(record.Severity) + " | Status: " + valueOrNone(record.Status) + " | Started " + formatIncidentAge(record, now) + " ago"
+	}
+
+	var own []IncidentRecord
+	for _, record := range active {
+		if record.CommanderID == userID || record.AcknowledgedBy == userID {
+			own = append(own, record)
+		}
+	}
+	addSection("Your open incidents", own, activeDetails)
+	addSection("Active incidents", active, activeDetails)
+	if len(resolved) > maxRecentResolutions {
+		resolved = resolved[:maxRecentResolutions]
+	}
+	addSection("Recently resolved", resolved, func(record IncidentRecord) string {
+		return "Severity: " + valueOrNone(record.Severity) + " | Resolved " + record.ResolvedAt.UTC().Format("2006-01-02 15:04 MST")
+	})
+	return blocks
+}
+
+// ******************************************************************************
+// Name				: publishHomeTab
+// Description: Function to show the current incidents on the App Home tab of a
+// 							user
+// ******************************************************************************
+func publishHomeTab(userID string) {
+	err := incidentStore.AddHomeViewer(userID)
+	if err != nil {
+		log.Error("publishHomeTab Error: ", err)
+	}
+	view := slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: getHomeBlocks(userID, time.Now())},
+	}
+	err = publishView(userID, view)
+	if err != nil {
+		log.Error("publishHomeTab Error: ", err)
+	}
+}
+
+// ******************************************************************************
+// Name				: refreshHomeTabs
+// Description: Function to refresh the App Home tab of every user who opened it.
+// 							The users are kept in the incident store across restarts
+// ******************************************************************************
+func refreshHomeTabs() {
+	for _, userID := range incidentStore.GetHomeViewers() {
+		publishHomeTab(userID)
+	}
+}
+
+// ******************************************************************************
+// Name				: scheduleHomeTabsRefresh
+// Description: Function to refresh the App Home tabs once homeRefreshDelay has
+// 							passed. Changes made in the meantime are shown by the same
+// 							refresh
+// ******************************************************************************
+func scheduleHomeTabsRefresh() {
+	homeRefresh.Lock()
+	defer homeRefresh.Unlock()
+	if homeRefresh.timer != nil {
+		return
+	}
+	homeRefresh.timer = time.AfterFunc(homeRefreshDelay, func() {
+		homeRefresh.Lock()
+		homeRefresh.timer = nil
+		homeRefresh.Unlock()
+		// Refreshes do not overlap when publishing takes longer than the delay
+		homeRefresh.running.Lock()
+		defer homeRefresh.running.Unlock()
+		refreshHomeTabs()
+	})
+}
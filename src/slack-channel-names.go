@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultChannelNameTemplate = "gl-{{lower key}}"
+
+// Slack channel names are at most 80 characters long
+const maxChannelNameLength = 80
+
+// Number of suffixes tried when a channel name is already taken
+const maxChannelNameAttempts = 10
+
+var invalidChannelNameCharacters = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+var repeatedChannelNameSeparators = regexp.MustCompile(`-{2,}`)
+
+// ChannelNameData holds what the name of an incident channel can be built from
+type ChannelNameData struct {
+	JiraKey  string
+	Title    string
+	Severity string
+	Services []string
+}
+
+// ******************************************************************************
+// Name				: slugify
+// Description: Function to turn a text into lower case words joined by hyphens
+// ******************************************************************************
+func slugify(text string) string {
+	text = invalidChannelNameCharacters.ReplaceAllString(strings.ToLower(text), "-")
+	return strings.Trim(repeatedChannelNameSeparators.ReplaceAllString(text, "-"), "-_")
+}
+
+// ******************************************************************************
+// Name				: sanitizeChannelName
+// Description: Function to make a name valid for a Slack channel: lower case
+// 							letters, numbers, hyphens and underscores only, at most 80
+// 							characters
+// ******************************************************************************
+func sanitizeChannelName(name string) string {
+	name = slugify(name)
+	if len(name) > maxChannelNameLength {
+		name = strings.Trim(name[:maxChannelNameLength], "-_")
+	}
+	return name
+}
+
+// ******************************************************************************
+// Name				: getChannelNameTemplate
+// Description: Function to get the naming template of the channel. The override
+// 							of the first affected service that has one wins over the
+// 							default template
+// ******************************************************************************
+func getChannelNameTemplate(services []string) string {
+	for _, service := range services {
+		if nameTemplate, ok := constants.Slack.Channels.ServiceNameTemplates[service]; ok && nameTemplate != "" {
+			return nameTemplate
+		}
+	}
+	if constants.Slack.Channels.NameTemplate != "" {
+		return constants.Slack.Channels.NameTemplate
+	}
+	return defaultChannelNameTemplate
+}
+
+// ******************************************************************************
+// Name				: renderChannelName
+// Description: Function to render a channel naming template. The template can
+// 							use date, key, title, severity and service, as well as the
+// 							slug and lower functions
+// ******************************************************************************
+func renderChannelName(nameTemplate string, data ChannelNameData) (string, error) {
+	service := "incident"
+	if len(data.Services) > 0 {
+		service = data.Services[0]
+		// Components are referenced as <page>/<component_id>
+		service = service[strings.LastIndex(service, "/")+1:]
+	}
+	funcs := template.FuncMap{
+		"date":     func() string { return time.Now().UTC().Format("20060102") },
+		"key":      func() string { return data.JiraKey },
+		"title":    func() string { return data.Title },
+		"severity": func() string { return data.Severity },
+		"service":  func() string { return service },
+		"slug":     slugify,
+		"lower":    strings.ToLower,
+	}
+	tmpl, err := template.New("channel").Funcs(funcs).Parse(nameTemplate)
+	if err != nil {
+		return "", err
+	}
+	var name bytes.Buffer
+	err = tmpl.Execute(&name, data)
+	if err != nil {
+		return "", err
+	}
+	return name.String(), nil
+}
+
+// ******************************************************************************
+// Name				: getChannelName
+// Description: Function to build the name of the channel of an incident. Falls
+// 							back to gl-<jira-key> when the template cannot be used
+// ******************************************************************************
+func getChannelName(data ChannelNameData) string {
+	name, err := renderChannelName(getChannelNameTemplate(data.Services), data)
+	if err != nil {
+		log.Error("getChannelName Template Error: ", err)
+	}
+	name = sanitizeChannelName(name)
+	if name == "" {
+		name = sanitizeChannelName("gl-" + data.JiraKey)
+	}
+	return name
+}
+
+// ******************************************************************************
+// Name				: getAlternativeChannelName
+// Description: Function to get the name to try when a channel name is taken,
+// 							e.g. inc-db-down-2 for the second attempt
+// ******************************************************************************
+func getAlternativeChannelName(name string, attempt int) string {
+	suffix := "-" + strconv.Itoa(attempt)
+	if len(name)+len(suffix) > maxChannelNameLength {
+		name = strings.Trim(name[:maxChannelNameLength-len(suffix)], "-_")
+	}
+	return name + suffix
+}
+
+// ******************************************************************************
+// Name				: isPrivateIncident
+// Description: Function to check if an incident gets a private channel, either
+// 							because it was declared as a security incident or because
+// 							it affects a service listed in slack.channels.private_services
+// ******************************************************************************
+func isPrivateIncident(security bool, services []string) bool {
+	if security {
+		return true
+	}
+	for _, service := range services {
+		for _, privateService := range constants.Slack.Channels.PrivateServices {
+			if service == privateService {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/slack-go/slack"
@@ -11,8 +13,14 @@ import (
 // Name				: createJIRAIssue
 // Description: Helper function to create JIRA ticket
 // ******************************************************************************
-func createJIRAIssue(issueTitle string, s slack.SlashCommand) (string, error) {
-	issue, err := createJiraIssue(issueTitle)
+func createJIRAIssue(declaration IncidentDeclaration, s slack.SlashCommand) (string, error) {
+	issue, err := createJiraIssue(JiraIssueData{
+		Title:       declaration.Title,
+		Description: declaration.Description,
+		Severity:    declaration.Severity,
+		Services:    declaration.Services,
+		DeclaredBy:  s.UserName,
+	})
 	if err != nil {
 		msg := "ERROR!! Error creating JIRA Issue: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain + ". " + constants.ValidationMessages.UseHelp
 		response := SlashResponse{"ephemeral", msg}
@@ -27,9 +35,15 @@ func createJIRAIssue(issueTitle string, s slack.SlashCommand) (string, error) {
 // Name				: createSlackChannel
 // Description: Helper function to create Slack Channel
 // ******************************************************************************
-func createSlackChannel(issueKey string, s slack.SlashCommand) (string, error) {
-	channelName := getChannelName(issueKey)
-	channel, err := createNewChannel(channelName, []User{})
+func createSlackChannel(issueKey string, declaration IncidentDeclaration, s slack.SlashCommand) (string, error) {
+	channelName := getChannelName(ChannelNameData{
+		JiraKey:  issueKey,
+		Title:    declaration.Title,
+		Severity: declaration.Severity,
+		Services: declaration.Services,
+	})
+	isPrivate := isPrivateIncident(declaration.Security, declaration.Services)
+	channel, err := createNewChannel(channelName, isPrivate, []User{})
 	if err != nil {
 		msg := "ERROR!! Error creating Slack Channel: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain + ". " + constants.ValidationMessages.UseHelp
 		response := SlashResponse{"ephemeral", msg}
@@ -44,55 +58,198 @@ func createSlackChannel(issueKey string, s slack.SlashCommand) (string, error) {
 // Name				: createStatusPage
 // Description: Helper function to create StatusPage Incident
 // ******************************************************************************
-func createStatusPage(s slack.SlashCommand, issueTitle string, severity string, componentIDList []string) (*StatusPageIncident, error) {
-	var description string
-	statusPageIncident, err := createStatusPageIncident(issueTitle, description, severity, componentIDList)
+func createStatusPage(s slack.SlashCommand, issueTitle string, description string, severity string, services []string) ([]StatusPageIncidentRef, error) {
+	routes := getStatusPageRoutes(services, getServiceSPComponents(services))
+	statusPageIncidents, err := createStatusPageIncidents(issueTitle, description, severity, routes)
 	if err != nil {
 		msg := "ERROR!! Error creating StatusPage Incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain + ". " + constants.ValidationMessages.UseHelp
 		response := SlashResponse{"ephemeral", msg}
 		slackCommandResponse(response, s)
-		return nil, err
+		if len(statusPageIncidents) == 0 {
+			return nil, err
+		}
 	}
-	log.Info("Status Page Created: ", statusPageIncident.ID)
-	return statusPageIncident, nil
+	for _, j := range statusPageIncidents {
+		log.Info("Status Page Created: ", j.IncidentID, " on page ", j.Page)
+	}
+	return statusPageIncidents, nil
 }
 
 // ******************************************************************************
 // Name				: updateStatePage
-// Description: Helper function to update StatusPage Incident
+// Description: Helper function to update StatusPage Incident. Every page is
+// 							tried, and a note lists the pages that could not be updated
+// 							when only some of them failed
 // ******************************************************************************
-func updateStatePage(arguments []string, statusPageLink string, s slack.SlashCommand) error {
-	_, err := updateStatusPageIncident(arguments, statusPageLink)
-	if err != nil {
+func updateStatePage(arguments []string, statusPageLinks []string, s slack.SlashCommand) (string, error) {
+	var updated []string
+	var failed []string
+	var err error
+	for _, statusPageLink := range statusPageLinks {
+		_, linkErr := updateStatusPageIncident([]string{arguments[0], arguments[1]}, statusPageLink)
+		if linkErr != nil {
+			log.Error("updateStatePage Error: ", linkErr)
+			failed = append(failed, statusPageLink)
+			err = linkErr
+			continue
+		}
+		updated = append(updated, statusPageLink)
+	}
+	if len(failed) == 0 {
+		return "", nil
+	}
+	if len(updated) == 0 {
 		msg := "ERROR!! Error updating StatusPage Incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain + ". " + constants.ValidationMessages.UseHelp
 		response := SlashResponse{"ephemeral", msg}
 		slackCommandResponse(response, s)
-		return errors.New("StatusPageUpdationError")
+		return "", errors.New("StatusPageUpdationError")
+	}
+	failedPages := strings.Join(getStatusPageLinkNames(s.ChannelID, failed), ", ")
+	return "StatusPage was updated on " + strings.Join(getStatusPageLinkNames(s.ChannelID, updated), ", ") +
+		" but not on " + failedPages + " (" + err.Error() + "). Post the update again with \"page=<page-name>\" for " + failedPages + ".", nil
+}
+
+// ******************************************************************************
+// Name				: publishStatusPageComment
+// Description: Helper function to post a comment to StatusPage according to the
+// 							visibility of the incident. Returns a note for the user when
+// 							the comment was not published right away
+// ******************************************************************************
+func publishStatusPageComment(arguments []string, statusPageLinks []string, s slack.SlashCommand) (string, error) {
+	record, err := incidentStore.FindByChannel(s.ChannelID)
+	visibility := visibilityPublic
+	if err == nil {
+		visibility = getIncidentVisibility(record)
+	}
+	switch visibility {
+	case visibilityInternal:
+		return "StatusPage was not updated as the incident is internal-only.", nil
+	case visibilityDelayedPublic:
+		if !canPublishStatusPage(s.UserID) {
+			update, err := queueStatusPageUpdate(record.ID, arguments[0], arguments[1], statusPageLinks, getPageArgument(arguments), s.UserID)
+			if err != nil {
+				msg := "ERROR!! Error queueing StatusPage update: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain + ". " + constants.ValidationMessages.UseHelp
+				response := SlashResponse{"ephemeral", msg}
+				slackCommandResponse(response, s)
+				return "", errors.New("StatusPageQueueError")
+			}
+			id := strconv.Itoa(update.ID)
+			return "StatusPage update #" + id + " is waiting for approval from " + getCommsMention() + " (/falcon \"approve\" \"" + id + "\" or /falcon \"reject\" \"" + id + "\").", nil
+		}
+	}
+	note, err := updateStatePage(arguments, statusPageLinks, s)
+	if err != nil {
+		return "", err
+	}
+	postStakeholderDigest(record, arguments[0], arguments[1])
+	return note, nil
+}
+
+// ******************************************************************************
+// Name				: getStatusPageLinksForComment
+// Description: Helper function to get the StatusPage incidents targeted by a
+// 							comment command
+// ******************************************************************************
+func getStatusPageLinksForComment(arguments []string, purposeLink string, s slack.SlashCommand) ([]string, error) {
+	record, err := incidentStore.FindByChannel(s.ChannelID)
+	if err == nil && len(record.StatusPageIncidents) == 0 {
+		switch getIncidentVisibility(record) {
+		case visibilityInternal:
+			// Internal incidents never post to StatusPage
+			return nil, nil
+		case visibilityDelayedPublic:
+			// Delayed-public incidents are created on StatusPage once an
+			// update is approved
+			if !canPublishStatusPage(s.UserID) {
+				return nil, nil
+			}
+			_, err = publishIncidentOnStatusPage(record)
+			if err != nil {
+				msg := "ERROR!! Error creating StatusPage Incident: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain
+				response := SlashResponse{"ephemeral", msg}
+				slackCommandResponse(response, s)
+				return nil, errors.New("StatusPageCreationError")
+			}
+		}
+	}
+	statusPageLinks, err := getStatusPageLinks(s.ChannelID, purposeLink, getPageArgument(arguments))
+	if err != nil {
+		msg := "ERROR!! " + err.Error() + "\n" + constants.ValidationMessages.UseHelp
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return nil, errors.New("StatusPageNotFoundError")
 	}
+	return statusPageLinks, nil
+}
+
+// ******************************************************************************
+// Name				: renderStatusPageMessage
+// Description: Helper function to replace a `template=<name>` comment argument
+// 							with the message template rendered for the first StatusPage
+// 							incident of the comment
+// ******************************************************************************
+func renderStatusPageMessage(arguments []string, statusPageLinks []string, s slack.SlashCommand) error {
+	if !isTemplateArgument(arguments[2]) {
+		return nil
+	}
+	if len(statusPageLinks) == 0 {
+		msg := "ERROR!! StatusPage templates can only be used once the incident is on StatusPage.\n" + constants.ValidationMessages.UseHelp
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return errors.New("StatusPageTemplateError")
+	}
+	message, err := renderStatusPageTemplate(getTemplateName(arguments[2]), arguments[1], statusPageLinks[0])
+	if err != nil {
+		msg := "ERROR!! Error rendering StatusPage template: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain + ". " + constants.ValidationMessages.UseHelp
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return errors.New("StatusPageTemplateError")
+	}
+	arguments[2] = message
 	return nil
 }
 
 // ******************************************************************************
 // Name				: addJiraComment
-// Description: Helper function to add comment on JIRA ticket
+// Description: Helper function to add comment on JIRA ticket and move it to the
+// 							given incident phase
 // ******************************************************************************
 func addJiraComment(jiraURL string, username string, arguments []string, jiraStatus string, s slack.SlashCommand) error {
-	_, _, err := addComment(jiraURL, s.UserName, arguments[2], jiraStatus)
+	_, _, err := addComment(jiraURL, s.UserID, arguments[2])
 	if err != nil {
 		msg := "ERROR!! Error updating JIRA Issue: " + err.Error() + "\n" + constants.ValidationMessages.TryAgain + ". " + constants.ValidationMessages.UseHelp
 		response := SlashResponse{"ephemeral", msg}
 		slackCommandResponse(response, s)
 		return errors.New("JiraAddCommentError")
 	}
+	transitionJiraIssueForCommand(getJiraKeyFromURL(jiraURL), jiraStatus, s)
 	return nil
 }
 
+// ******************************************************************************
+// Name				: transitionJiraIssueForCommand
+// Description: Helper function to move the JIRA ticket of the incident to an
+// 							incident phase. Failures are reported to the user without
+// 							stopping the command, as the update was already posted
+// ******************************************************************************
+func transitionJiraIssueForCommand(issueKey string, phase string, s slack.SlashCommand) {
+	if issueKey == "" || phase == "" || phase == "current" {
+		return
+	}
+	err := transitionIncidentJiraIssue(s.ChannelID, issueKey, phase)
+	if err != nil {
+		msg := "WARNING!! The JIRA issue " + issueKey + " could not be moved to " + phase + ": " + err.Error()
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+	}
+}
+
 // ******************************************************************************
 // Name				: setSlackChannelPurpose
 // Description: Helper function to add slack channel description
 // ******************************************************************************
-func setSlackChannelPurpose(s slack.SlashCommand, statusPageIncident *StatusPageIncident, jiraURL string, channelID string) error {
-	purpose := prepareSlackChannelPurpose(statusPageIncident.ID, statusPageIncident.Shortlink, jiraURL)
+func setSlackChannelPurpose(s slack.SlashCommand, statusPageIncident StatusPageIncidentRef, jiraURL string, channelID string) error {
+	purpose := prepareSlackChannelPurpose(statusPageIncident.IncidentID, statusPageIncident.Shortlink, jiraURL)
 	var err error
 	if channelID == "" {
 		_, err = setChannelPurpose(s.ChannelID, purpose)
@@ -109,6 +266,22 @@ func setSlackChannelPurpose(s slack.SlashCommand, statusPageIncident *StatusPage
 	return nil
 }
 
+// ******************************************************************************
+// Name				: getIncidentForCommand
+// Description: Helper function to get the incident of the channel from which a
+// 							command is used
+// ******************************************************************************
+func getIncidentForCommand(s slack.SlashCommand) (IncidentRecord, error) {
+	record, err := incidentStore.FindByChannel(s.ChannelID)
+	if err != nil {
+		msg := "ERROR!! No incident found for this channel. \n" + "Please use the command from the incident channel or name the incident with inc=<incident>."
+		response := SlashResponse{"ephemeral", msg}
+		slackCommandResponse(response, s)
+		return record, err
+	}
+	return record, nil
+}
+
 // ******************************************************************************
 // Name				: respondWithHelpMessage
 // Description: This check is needed if a user just types help after /falcon
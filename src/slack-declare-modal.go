@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+const declareCallbackID = "declare_incident"
+
+// Block and action ids of the inputs of the declare incident modal
+const (
+	declareTitleBlock       = "title"
+	declareDescriptionBlock = "description"
+	declareSeverityBlock    = "severity"
+	declareComponentsBlock  = "components"
+	declareTeamBlock        = "team"
+	declareStatusPageBlock  = "statuspage"
+	declareStatusPageOption = "create"
+	declareSecurityBlock    = "security"
+	declareSecurityOption   = "private"
+)
+
+// Slack allows at most 100 options in a select menu
+const maxSelectOptions = 100
+
+// DeclareModalMetadata is kept in the private metadata of the modal to respond
+// in the place the modal was opened from
+type DeclareModalMetadata struct {
+	ChannelID   string `json:"channel_id,omitempty"`
+	ResponseURL string `json:"response_url,omitempty"`
+}
+
+// ******************************************************************************
+// Name				: openDeclareModal
+// Description: Function to open the modal used to declare a new incident. The
+// 							trigger id expires after 3 seconds, so a placeholder is opened
+// 							first and filled in once the options are loaded
+// ******************************************************************************
+func openDeclareModal(triggerID string, metadata DeclareModalMetadata) error {
+	privateMetadata, _ := json.Marshal(metadata)
+	modal := slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      declareCallbackID,
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, "Declare an incident", false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		PrivateMetadata: string(privateMetadata),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, "Loading...", false, false), nil, nil),
+		}},
+	}
+	view, err := openModalView(triggerID, modal)
+	if err != nil {
+		return err
+	}
+	modal.Submit = slack.NewTextBlockObject(slack.PlainTextType, "Declare", false, false)
+	modal.Blocks = slack.Blocks{BlockSet: getDeclareModalBlocks()}
+	return updateModal(view, modal)
+}
+
+// ******************************************************************************
+// Name				: getDeclareModalBlocks
+// Description: Function to build the inputs of the declare incident modal
+// ******************************************************************************
+func getDeclareModalBlocks() []slack.Block {
+	title := slack.NewInputBlock(declareTitleBlock,
+		slack.NewTextBlockObject(slack.PlainTextType, "Title", false, false),
+		slack.NewPlainTextInputBlockElement(slack.NewTextBlockObject(slack.PlainTextType, "What is broken?", false, false), declareTitleBlock))
+
+	descriptionElement := slack.NewPlainTextInputBlockElement(nil, declareDescriptionBlock)
+	descriptionElement.Multiline = true
+	description := slack.NewInputBlock(declareDescriptionBlock,
+		slack.NewTextBlockObject(slack.PlainTextType, "Description", false, false), descriptionElement)
+	description.Optional = true
+
+	var severityOptions []*slack.OptionBlockObject
+	for _, j := range [][]string{{"minor", "Minor"}, {"major", "Major"}, {"critical", "Critical"}} {
+		severityOptions = append(severityOptions, getOption(j[0], j[1]))
+	}
+	severityElement := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, nil, declareSeverityBlock, severityOptions...)
+	severityElement.InitialOption = severityOptions[0]
+	severity := slack.NewInputBlock(declareSeverityBlock,
+		slack.NewTextBlockObject(slack.PlainTextType, "Severity", false, false), severityElement)
+
+	blocks := []slack.Block{title, description, severity}
+
+	componentOptions := getDeclareComponentOptions()
+	if len(componentOptions) > 0 {
+		components := slack.NewInputBlock(declareComponentsBlock,
+			slack.NewTextBlockObject(slack.PlainTextType, "Affected components", false, false),
+			slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeStatic, nil, declareComponentsBlock, componentOptions...))
+		components.Optional = true
+		blocks = append(blocks, components)
+	}
+
+	teamOptions := getDeclareTeamOptions()
+	if len(teamOptions) > 0 {
+		team := slack.NewInputBlock(declareTeamBlock,
+			slack.NewTextBlockObject(slack.PlainTextType, "Team", false, false),
+			slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, nil, declareTeamBlock, teamOptions...))
+		team.Optional = true
+		blocks = append(blocks, team)
+	}
+
+	statusPageOption := getOption(declareStatusPageOption, "Create StatusPage incident")
+	statusPageElement := slack.NewCheckboxGroupsBlockElement(declareStatusPageBlock, statusPageOption)
+	statusPageElement.InitialOptions = []*slack.OptionBlockObject{statusPageOption}
+	statusPage := slack.NewInputBlock(declareStatusPageBlock,
+		slack.NewTextBlockObject(slack.PlainTextType, "StatusPage", false, false), statusPageElement)
+	statusPage.Optional = true
+
+	securityElement := slack.NewCheckboxGroupsBlockElement(declareSecurityBlock, getOption(declareSecurityOption, "Security incident (private channel)"))
+	security := slack.NewInputBlock(declareSecurityBlock,
+		slack.NewTextBlockObject(slack.PlainTextType, "Security", false, false), securityElement)
+	security.Optional = true
+
+	return append(blocks, statusPage, security)
+}
+
+// ******************************************************************************
+// Name				: getOption
+// Description: Function to build a plain text option of a select menu
+// ******************************************************************************
+func getOption(value string, text string) *slack.OptionBlockObject {
+	// Slack limits option texts to 75 characters, not bytes
+	if runes := []rune(text); len(runes) > 75 {
+		text = string(runes[:72]) + "..."
+	}
+	return slack.NewOptionBlockObject(value, slack.NewTextBlockObject(slack.PlainTextType, text, false, false), nil)
+}
+
+// ******************************************************************************
+// Name				: getDeclareComponentOptions
+// Description: Function to get the component options of the modal. Services
+// 							from the StatusPage mappings come first, followed by the
+// 							components of the configured StatusPage pages, referenced
+// 							as <page>/<component_id>
+// ******************************************************************************
+func getDeclareComponentOptions() []*slack.OptionBlockObject {
+	var options []*slack.OptionBlockObject
+	seen := map[string]bool{}
+	addOption := func(value string, text string) {
+		if seen[value] || len(options) >= maxSelectOptions {
+			return
+		}
+		seen[value] = true
+		options = append(options, getOption(value, text))
+	}
+
+	statusPageMappingsInitializer()
+	for _, j := range statusPageMappings.StatusPageMappings {
+		addOption(j.Service, j.Service)
+	}
+
+	pages := []string{defaultStatusPageName}
+	for _, page := range constants.StatusPage.Pages {
+		pages = append(pages, page.Name)
+	}
+	for _, name := range pages {
+		page, _ := getStatusPage(name)
+		if page.PageID == "" {
+			continue
+		}
+		components, err := listStatusPageComponents(page.PageID)
+		if err != nil {
+			continue
+		}
+		for _, component := range components {
+			if component.ID == nil || component.Name == nil {
+				continue
+			}
+			addOption(page.Name+"/"+*component.ID, *component.Name+" ("+page.Name+")")
+		}
+	}
+	return options
+}
+
+// ******************************************************************************
+// Name				: getDeclareTeamOptions
+// Description: Function to get the slack user groups a new incident can be
+// 							assigned to
+// ******************************************************************************
+func getDeclareTeamOptions() []*slack.OptionBlockObject {
+	userGroups, err := getUserGroups()
+	if err != nil {
+		return nil
+	}
+	var options []*slack.OptionBlockObject
+	for _, j := range userGroups {
+		if len(options) >= maxSelectOptions {
+			break
+		}
+		options = append(options, getOption(j.ID, "@"+j.Handle))
+	}
+	return options
+}
+
+// ******************************************************************************
+// Name				: getDeclaration
+// Description: Function to read the incident declaration from a submitted modal
+// ******************************************************************************
+func getDeclaration(view slack.View) IncidentDeclaration {
+	values := map[string]map[string]slack.BlockAction{}
+	if view.State != nil {
+		values = view.State.Values
+	}
+	declaration := IncidentDeclaration{
+		Title:       strings.TrimSpace(values[declareTitleBlock][declareTitleBlock].Value),
+		Description: strings.TrimSpace(values[declareDescriptionBlock][declareDescriptionBlock].Value),
+		Severity:    values[declareSeverityBlock][declareSeverityBlock].SelectedOption.Value,
+		TeamID:      values[declareTeamBlock][declareTeamBlock].SelectedOption.Value,
+		Visibility:  visibilityPublic,
+	}
+	for _, j := range values[declareComponentsBlock][declareComponentsBlock].SelectedOptions {
+		declaration.Services = append(declaration.Services, j.Value)
+	}
+	for _, j := range values[declareStatusPageBlock][declareStatusPageBlock].SelectedOptions {
+		if j.Value == declareStatusPageOption {
+			declaration.CreateStatusPage = true
+		}
+	}
+	for _, j := range values[declareSecurityBlock][declareSecurityBlock].SelectedOptions {
+		if j.Value == declareSecurityOption {
+			declaration.Security = true
+		}
+	}
+	return declaration
+}
+
+// ******************************************************************************
+// Name				: declareModalSubmission
+// Description: Function to handle a submitted declare incident modal. Returns
+// 							the validation errors to show in the modal, if any
+// ******************************************************************************
+func declareModalSubmission(callback slack.InteractionCallback) *slack.ViewSubmissionResponse {
+	declaration := getDeclaration(callback.View)
+	if declaration.Title == "" {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{declareTitleBlock: "Please enter a title"})
+	}
+
+	var metadata DeclareModalMetadata
+	if callback.View.PrivateMetadata != "" {
+		err := json.Unmarshal([]byte(callback.View.PrivateMetadata), &metadata)
+		if err != nil {
+			log.Error("declareModalSubmission Metadata Error: ", err)
+		}
+	}
+	s := slack.SlashCommand{
+		UserID:      callback.User.ID,
+		UserName:    callback.User.Name,
+		ChannelID:   metadata.ChannelID,
+		ResponseURL: metadata.ResponseURL,
+		TriggerID:   callback.TriggerID,
+		Text:        declaration.Title,
+	}
+	go declareIncident(s, declaration)
+	return nil
+}
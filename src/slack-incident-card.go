@@ -0,0 +1,158 @@
+package main
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// ******************************************************************************
+// Name				: getIncidentLinks
+// Description: Function to get the titles and URLs of the pages related to an
+// 							incident, in the order they are shown
+// ******************************************************************************
+func getIncidentLinks(record IncidentRecord) [][]string {
+	var links [][]string
+	if record.JiraKey != "" {
+		links = append(links, []string{"JIRA " + record.JiraKey, constants.JIRA.Endpoint + "/browse/" + record.JiraKey})
+	}
+	for _, ref := range record.StatusPageIncidents {
+		if ref.Shortlink == "" {
+			continue
+		}
+		title := "StatusPage"
+		if ref.Page != "" && ref.Page != defaultStatusPageName {
+			title += " (" + ref.Page + ")"
+		}
+		links = append(links, []string{title, ref.Shortlink})
+	}
+	if record.PagerDutyURL != "" {
+		links = append(links, []string{"PagerDuty", record.PagerDutyURL})
+	}
+	return links
+}
+
+// ******************************************************************************
+// Name				: getUserMention
+// Description: Function to mention a slack user, or show a placeholder
+// ******************************************************************************
+func getUserMention(userID string) string {
+	if userID == "" {
+		return "-"
+	}
+	return "<@" + userID + ">"
+}
+
+// ******************************************************************************
+// Name				: getIncidentCardBlocks
+// Description: Function to build the incident card pinned in the incident
+// 							channel
+// ******************************************************************************
+func getIncidentCardBlocks(record IncidentRecord) []slack.Block {
+	title := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, ":clipboard: *"+record.Title+"*", false, false), nil, nil)
+
+	components := "-"
+	if len(record.Services) > 0 {
+		components = strings.Join(record.Services, ", ")
+	}
+	fields := []*slack.TextBlockObject{
+		slack.NewTextBlockObject(slack.MarkdownType, "*Status*\n"+valueOrNone(record.Status), false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Severity*\n"+valueOrNone(record.Severity), false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Commander*\n"+getUserMention(record.CommanderID), false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Acknowledged by*\n"+getUserMention(record.AcknowledgedBy), false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Visibility*\n"+getIncidentVisibility(record), false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Components*\n"+components, false, false),
+	}
+	details := slack.NewSectionBlock(nil, fields, nil)
+
+	var links []string
+	for _, link := range getIncidentLinks(record) {
+		links = append(links, "<"+link[1]+"|"+link[0]+">")
+	}
+	blocks := []slack.Block{title, details}
+	if len(links) > 0 {
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, strings.Join(links, " | "), false, false)))
+	}
+	return blocks
+}
+
+// ******************************************************************************
+// Name				: setupIncidentChannel
+// Description: Function to bookmark the incident links in the incident channel
+// 							and pin the incident card in it
+// ******************************************************************************
+func setupIncidentChannel(record IncidentRecord) {
+	if record.ChannelID == "" {
+		return
+	}
+	addIncidentBookmarks(record)
+
+	text := "Incident: " + record.Title
+	timestamp, err := postBlocksToChannel(record.ChannelID, text, getIncidentCardBlocks(record))
+	if err != nil {
+		return
+	}
+	card := SlackMessageRef{ChannelID: record.ChannelID, Timestamp: timestamp}
+	pinMessage(card)
+	_, err = incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.CardMessage = &card
+	})
+	if err != nil {
+		log.Error("setupIncidentChannel Error: ", err)
+	}
+	// New incidents show up on the App Home tabs
+	scheduleHomeTabsRefresh()
+}
+
+// ******************************************************************************
+// Name				: addIncidentBookmarks
+// Description: Function to bookmark the incident links that are not bookmarked
+// 							in the incident channel yet
+// ******************************************************************************
+func addIncidentBookmarks(record IncidentRecord) {
+	var added []string
+	for _, link := range getIncidentLinks(record) {
+		if isBookmarked(record, link[1]) {
+			continue
+		}
+		if addChannelBookmark(record.ChannelID, link[0], link[1]) == nil {
+			added = append(added, link[1])
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	_, err := incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.Bookmarks = append(record.Bookmarks, added...)
+	})
+	if err != nil {
+		log.Error("addIncidentBookmarks Error: ", err)
+	}
+}
+
+// ******************************************************************************
+// Name				: isBookmarked
+// Description: Function to check if a link is bookmarked in the incident channel
+// ******************************************************************************
+func isBookmarked(record IncidentRecord, link string) bool {
+	for _, j := range record.Bookmarks {
+		if j == link {
+			return true
+		}
+	}
+	return false
+}
+
+// ******************************************************************************
+// Name				: updateIncidentCard
+// Description: Function to show the current state of the incident on its
+// 							pinned card
+// ******************************************************************************
+func updateIncidentCard(record IncidentRecord) {
+	if record.CardMessage == nil || record.CardMessage.Timestamp == "" {
+		return
+	}
+	updateSlackMessage(*record.CardMessage, "Incident: "+record.Title, getIncidentCardBlocks(record))
+	addIncidentBookmarks(record)
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// Action ids of the buttons on the incident alert messages
+const (
+	joinChannelAction      = "join_channel"
+	acknowledgeAction      = "acknowledge"
+	updateStatusAction     = "update_status"
+	escalateSeverityAction = "escalate_severity"
+	resolveAction          = "resolve"
+)
+
+const updateStatusCallbackID = "update_status"
+
+// Block and action ids of the inputs of the update status modal
+const (
+	updateStatusStatusBlock  = "status"
+	updateStatusCommentBlock = "comment"
+)
+
+var severityLevels = []string{"minor", "major", "critical"}
+
+// UpdateStatusModalMetadata is kept in the private metadata of the update
+// status modal to know which incident is updated
+type UpdateStatusModalMetadata struct {
+	IncidentID int `json:"incident_id"`
+}
+
+// ******************************************************************************
+// Name				: getIncidentMessageBlocks
+// Description: Function to build the alert message of an incident with its
+// 							current state and the actions that can be taken on it
+// ******************************************************************************
+func getIncidentMessageBlocks(record IncidentRecord) []slack.Block {
+	incidentID := strconv.Itoa(record.ID)
+	text := ":rotating_light: *Incident Alert: " + record.Title + "*\nAll relevant members are requested to join the group <#" + record.ChannelID + ">"
+	fields := []*slack.TextBlockObject{
+		slack.NewTextBlockObject(slack.MarkdownType, "*Severity*\n"+valueOrNone(record.Severity), false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Status*\n"+valueOrNone(record.Status), false, false),
+	}
+	if record.AcknowledgedBy != "" {
+		fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, "*Acknowledged by*\n<@"+record.AcknowledgedBy+">", false, false))
+	}
+	if record.JiraKey != "" {
+		fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, "*Jira*\n<"+constants.JIRA.Endpoint+"/browse/"+record.JiraKey+"|"+record.JiraKey+">", false, false))
+	}
+	section := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), fields, nil)
+
+	buttons := []slack.BlockElement{
+		slack.NewButtonBlockElement(joinChannelAction, incidentID, slack.NewTextBlockObject(slack.PlainTextType, "Join channel", false, false)).WithStyle(slack.StylePrimary),
+	}
+	if record.Status != "resolved" {
+		if record.AcknowledgedBy == "" {
+			buttons = append(buttons, slack.NewButtonBlockElement(acknowledgeAction, incidentID, slack.NewTextBlockObject(slack.PlainTextType, "Acknowledge", false, false)))
+		}
+		buttons = append(buttons, slack.NewButtonBlockElement(updateStatusAction, incidentID, slack.NewTextBlockObject(slack.PlainTextType, "Update status", false, false)))
+		if getNextSeverity(record.Severity) != "" {
+			buttons = append(buttons, slack.NewButtonBlockElement(escalateSeverityAction, incidentID, slack.NewTextBlockObject(slack.PlainTextType, "Escalate severity", false, false)))
+		}
+		buttons = append(buttons, slack.NewButtonBlockElement(resolveAction, incidentID, slack.NewTextBlockObject(slack.PlainTextType, "Resolve", false, false)).WithStyle(slack.StyleDanger))
+	}
+	actions := slack.NewActionBlock("incident_actions_"+incidentID, buttons...)
+	return []slack.Block{section, actions}
+}
+
+// ******************************************************************************
+// Name				: valueOrNone
+// Description: Function to show a placeholder for empty incident fields
+// ******************************************************************************
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// ******************************************************************************
+// Name				: getNextSeverity
+// Description: Function to get the severity an incident is escalated to.
+// 							Incidents without a severity are treated as minor, so they are
+// 							escalated to major. Returns an empty string for critical incidents
+// ******************************************************************************
+func getNextSeverity(severity string) string {
+	if severity == "" {
+		return severityLevels[1]
+	}
+	for i, j := range severityLevels {
+		if j == severity && i+1 < len(severityLevels) {
+			return severityLevels[i+1]
+		}
+	}
+	return ""
+}
+
+// ******************************************************************************
+// Name				: postIncidentAlert
+// Description: Function to post the alert message of an incident to the
+// 							notification channels that do not have it yet and remember
+// 							where it was posted. Incidents with a private channel are not
+// 							announced
+// ******************************************************************************
+func postIncidentAlert(record IncidentRecord) {
+	if record.PrivateChannel {
+		log.Info("Not posting alerts for incident with private channel ", record.ChannelID)
+		return
+	}
+	var channelIDs []string
+	for _, channelID := range getNotificationChannels(record.Severity) {
+		if !hasAlertMessage(record, channelID) {
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+	messages := postMessageToSlackChannel(record, channelIDs)
+	if len(messages) == 0 {
+		return
+	}
+	_, err := incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		record.AlertMessages = append(record.AlertMessages, messages...)
+	})
+	if err != nil {
+		log.Error("postIncidentAlert Error: ", err)
+	}
+}
+
+// ******************************************************************************
+// Name				: incidentStateChanged
+// Description: Function to refresh everything that shows the state of an
+// 							incident after it changed
+// ******************************************************************************
+func incidentStateChanged(record IncidentRecord) {
+	text := "Incident Alert: " + record.Title
+	blocks := getIncidentMessageBlocks(record)
+	for _, message := range record.AlertMessages {
+		updateSlackMessage(message, text, blocks)
+	}
+	updateIncidentCard(record)
+	scheduleHomeTabsRefresh()
+}
+
+// ******************************************************************************
+// Name				: getActionCommand
+// Description: Function to build the command context for an action taken on an
+// 							incident message, so that it can be handled like a slash
+// 							command used from the incident channel
+// ******************************************************************************
+func getActionCommand(callback slack.InteractionCallback, record IncidentRecord) slack.SlashCommand {
+	return slack.SlashCommand{
+		UserID:    callback.User.ID,
+		UserName:  callback.User.Name,
+		ChannelID: record.ChannelID,
+		TriggerID: callback.TriggerID,
+	}
+}
+
+// ******************************************************************************
+// Name				: incidentActionService
+// Description: Function to perform the action of a button on an incident message
+// ******************************************************************************
+func incidentActionService(callback slack.InteractionCallback, action *slack.BlockAction) {
+	id, err := strconv.Atoi(action.Value)
+	if err != nil {
+		log.Error("incidentActionService Invalid Incident: ", action.Value)
+		return
+	}
+	record, err := incidentStore.FindByID(id)
+	if err != nil {
+		log.Error("incidentActionService Incident not found: ", id)
+		return
+	}
+	s := getActionCommand(callback, record)
+
+	switch action.ActionID {
+	case escalateSeverityAction, updateStatusAction, resolveAction:
+		// Only responders may change the incident from its alert
+		if !isIncidentResponder(record, s.UserID) {
+			msg := "ERROR!! Only the incident commander and members of the incident channel can change the incident. Use Join channel first."
+			slackCommandResponse(SlashResponse{"ephemeral", msg}, s)
+			return
+		}
+	}
+
+	switch action.ActionID {
+	case joinChannelAction:
+		err = inviteUsersToChannel(record.ChannelID, []string{s.UserID})
+		if err != nil {
+			msg := "ERROR!! Error joining the incident channel: " + err.Error()
+			slackAPIResponse(SlashResponse{"ephemeral", msg}, slack.SlashCommand{UserID: s.UserID})
+		}
+	case acknowledgeAction:
+		updateIncidentForChannel(record.ChannelID, func(record *IncidentRecord) {
+			record.AcknowledgedBy = s.UserID
+			if record.CommanderID == "" {
+				record.CommanderID = s.UserID
+			}
+		})
+		slackCommandResponse(SlashResponse{"in_channel", "Incident acknowledged by <@" + s.UserID + ">"}, s)
+	case escalateSeverityAction:
+		severity := getNextSeverity(record.Severity)
+		if severity == "" {
+			slackCommandResponse(SlashResponse{"ephemeral", "The incident is already critical"}, s)
+			return
+		}
+		severityCommandService(s, []string{"severity", severity})
+	case updateStatusAction:
+		openUpdateStatusModal(callback.TriggerID, record, record.Status)
+	case resolveAction:
+		openUpdateStatusModal(callback.TriggerID, record, "resolved")
+	}
+}
+
+// ******************************************************************************
+// Name				: openUpdateStatusModal
+// Description: Function to open the modal used to post a status update for an
+// 							incident
+// ******************************************************************************
+func openUpdateStatusModal(triggerID string, record IncidentRecord, status string) error {
+	privateMetadata, _ := json.Marshal(UpdateStatusModalMetadata{IncidentID: record.ID})
+
+	var statusOptions []*slack.OptionBlockObject
+	var initialOption *slack.OptionBlockObject
+	for _, j := range []string{"investigating", "identified", "monitoring", "resolved"} {
+		option := getOption(j, strings.ToUpper(j[:1])+j[1:])
+		if j == status {
+			initialOption = option
+		}
+		statusOptions = append(statusOptions, option)
+	}
+	statusElement := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, nil, updateStatusStatusBlock, statusOptions...)
+	statusElement.InitialOption = initialOption
+	statusInput := slack.NewInputBlock(updateStatusStatusBlock,
+		slack.NewTextBlockObject(slack.PlainTextType, "Status", false, false), statusElement)
+
+	commentElement := slack.NewPlainTextInputBlockElement(nil, updateStatusCommentBlock)
+	commentElement.Multiline = true
+	commentInput := slack.NewInputBlock(updateStatusCommentBlock,
+		slack.NewTextBlockObject(slack.PlainTextType, "Comment for JIRA and StatusPage", false, false), commentElement)
+
+	title := record.Title
+	if runes := []rune(title); len(runes) > 100 {
+		title = string(runes[:97]) + "..."
+	}
+	modal := slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      updateStatusCallbackID,
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, "Update status", false, false),
+		Submit:          slack.NewTextBlockObject(slack.PlainTextType, "Update", false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		PrivateMetadata: string(privateMetadata),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "*"+title+"*", false, false), nil, nil),
+			statusInput,
+			commentInput,
+		}},
+	}
+	return openModal(triggerID, modal)
+}
+
+// ******************************************************************************
+// Name				: updateStatusModalSubmission
+// Description: Function to handle a submitted update status modal the same way
+// 							as the `comment` command
+// ******************************************************************************
+func updateStatusModalSubmission(callback slack.InteractionCallback) *slack.ViewSubmissionResponse {
+	var metadata UpdateStatusModalMetadata
+	err := json.Unmarshal([]byte(callback.View.PrivateMetadata), &metadata)
+	if err != nil {
+		log.Error("updateStatusModalSubmission Metadata Error: ", err)
+		return nil
+	}
+	record, err := incidentStore.FindByID(metadata.IncidentID)
+	if err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{updateStatusCommentBlock: "The incident could not be found"})
+	}
+	values := map[string]map[string]slack.BlockAction{}
+	if callback.View.State != nil {
+		values = callback.View.State.Values
+	}
+	status := values[updateStatusStatusBlock][updateStatusStatusBlock].SelectedOption.Value
+	comment := strings.TrimSpace(values[updateStatusCommentBlock][updateStatusCommentBlock].Value)
+	if status == "" {
+		status = "current"
+	}
+	if comment == "" {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{updateStatusCommentBlock: "Please enter a comment"})
+	}
+
+	s := getActionCommand(callback, record)
+	s.Text = "\"comment\" \"" + status + "\" \"" + comment + "\""
+	arguments := []string{"comment", status, comment}
+	resp, err := parseCommandArguments(s, arguments)
+	if err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{updateStatusCommentBlock: resp})
+	}
+	go commentCommandService(s, arguments)
+	return nil
+}
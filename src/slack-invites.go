@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// slackUserIDCache maps emails to the IDs of the slack users they resolved to
+var slackUserIDCache sync.Map
+
+// slackUserNameCache maps the IDs of slack users to their display names
+var slackUserNameCache sync.Map
+
+// InviteFailure describes a user that could not be added to an incident channel
+type InviteFailure struct {
+	Name   string
+	Reason string
+}
+
+// ******************************************************************************
+// Name				: getPDUserName
+// Description: Function to get a readable name of a pagerduty user for messages
+// ******************************************************************************
+func getPDUserName(user User) string {
+	switch {
+	case user.Summary != "" && user.Email != "":
+		return user.Summary + " (" + user.Email + ")"
+	case user.Email != "":
+		return user.Email
+	case user.Summary != "":
+		return user.Summary
+	}
+	return user.ID
+}
+
+// ******************************************************************************
+// Name				: resolveSlackUsers
+// Description: Function to find the slack users of pagerduty users by email.
+// 							Users that cannot be found are returned as failures instead
+// 							of stopping the lookup of the others
+// ******************************************************************************
+func resolveSlackUsers(users []User) ([]string, []InviteFailure) {
+	var userIDs []string
+	var failures []InviteFailure
+	for _, user := range users {
+		if user.Email == "" {
+			failures = append(failures, InviteFailure{getPDUserName(user), "no email in PagerDuty"})
+			continue
+		}
+		userID, err := getSlackUserIDByEmail(user.Email)
+		if err != nil {
+			failures = append(failures, InviteFailure{getPDUserName(user), "no Slack user found (" + err.Error() + ")"})
+			continue
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, failures
+}
+
+// ******************************************************************************
+// Name				: inviteUsersBestEffort
+// Description: Function to invite users to a channel. When inviting everyone at
+// 							once fails, the users are invited one by one so that a single
+// 							deactivated account does not keep the others out
+// ******************************************************************************
+func inviteUsersBestEffort(channelID string, userIDs []string) []InviteFailure {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	if inviteUsersToChannel(channelID, userIDs) == nil {
+		return nil
+	}
+	var failures []InviteFailure
+	for _, userID := range userIDs {
+		err := inviteUsersToChannel(channelID, []string{userID})
+		if err != nil {
+			failures = append(failures, InviteFailure{"<@" + userID + ">", err.Error()})
+		}
+	}
+	return failures
+}
+
+// ******************************************************************************
+// Name				: postInviteSummary
+// Description: Function to let the incident channel know who could not be
+// 							invited
+// ******************************************************************************
+func postInviteSummary(channelID string, failures []InviteFailure) {
+	if len(failures) == 0 {
+		return
+	}
+	lines := []string{"The following responders could not be added to this channel, please invite them manually:"}
+	for _, failure := range failures {
+		log.Warn("Could not invite ", failure.Name, " to ", channelID, ": ", failure.Reason)
+		lines = append(lines, "• "+failure.Name+" - "+failure.Reason)
+	}
+	postMessageToChannel(channelID, strings.Join(lines, "\n"))
+}
@@ -22,6 +22,10 @@ type SlashResponse struct {
 // Description: Function to make a POST request to send response back to Slack
 // ******************************************************************************
 func slackCommandResponse(response SlashResponse, s slack.SlashCommand) {
+	if s.ResponseURL == "" {
+		slackAPIResponse(response, s)
+		return
+	}
 	json, _ := json.Marshal(response)
 	reqBody := bytes.NewBuffer(json)
 	endpoint := s.ResponseURL
@@ -42,6 +46,33 @@ func slackCommandResponse(response SlashResponse, s slack.SlashCommand) {
 	defer resp.Body.Close()
 }
 
+// ******************************************************************************
+// Name				: slackAPIResponse
+// Description: Function to send a response through the Slack API for commands
+// 							that were not triggered by a slash command and thus have no
+// 							response url. Responses go to the user directly when there
+// 							is no channel
+// ******************************************************************************
+func slackAPIResponse(response SlashResponse, s slack.SlashCommand) {
+	slackAPI := slack.New(os.Getenv("SLACK_ACCESS_TOKEN"))
+	var err error
+	if s.ChannelID == "" {
+		_, _, err = slackAPI.PostMessage(s.UserID, slack.MsgOptionText(response.Text, false))
+	} else if response.ResponseType == "ephemeral" {
+		_, err = slackAPI.PostEphemeral(s.ChannelID, s.UserID, slack.MsgOptionText(response.Text, false))
+		if err != nil {
+			// The user might not be a member of the channel, e.g. when using the
+			// buttons of an incident alert
+			_, _, err = slackAPI.PostMessage(s.UserID, slack.MsgOptionText(response.Text, false))
+		}
+	} else {
+		_, _, err = slackAPI.PostMessage(s.ChannelID, slack.MsgOptionText(response.Text, false))
+	}
+	if err != nil {
+		log.Error("slackAPIResponse Error: ", err)
+	}
+}
+
 // ******************************************************************************
 // Name				: slashHelpResponse
 // Description: Function to send help response
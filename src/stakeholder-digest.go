@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ******************************************************************************
+// Name				: getNotificationChannels
+// Description: Function to get the channels incidents of a severity are
+// 							announced in: slack.notification_channel_ids and the
+// 							channels of the severity in
+// 							slack.severity_notification_channel_ids
+// ******************************************************************************
+func getNotificationChannels(severity string) []string {
+	var channelIDs []string
+	seen := map[string]bool{}
+	addChannel := func(channelID string) {
+		channelID = strings.TrimSpace(channelID)
+		if channelID == "" || seen[channelID] {
+			return
+		}
+		seen[channelID] = true
+		channelIDs = append(channelIDs, channelID)
+	}
+	for _, channelID := range strings.Split(constants.Slack.NotificationChannelIDs, ",") {
+		addChannel(channelID)
+	}
+	for _, channelID := range constants.Slack.SeverityNotificationChannelIDs[severity] {
+		addChannel(channelID)
+	}
+	return channelIDs
+}
+
+// ******************************************************************************
+// Name				: hasAlertMessage
+// Description: Function to check if the incident was already announced in a
+// 							channel
+// ******************************************************************************
+func hasAlertMessage(record IncidentRecord, channelID string) bool {
+	for _, message := range record.AlertMessages {
+		if message.ChannelID == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// ******************************************************************************
+// Name				: postStakeholderDigest
+// Description: Function to post an update of the incident in the thread of its
+// 							alert in every notification channel. Resolutions are also
+// 							shown in the channels
+// ******************************************************************************
+func postStakeholderDigest(record IncidentRecord, status string, body string) {
+	if len(record.AlertMessages) == 0 {
+		return
+	}
+	var text string
+	if status == "resolved" {
+		text = ":white_check_mark: *Resolved*"
+	} else if status == "" || status == "current" {
+		text = ":memo: *Update*"
+	} else {
+		text = ":memo: *Update* (" + status + ")"
+	}
+	if body != "" {
+		text += ": " + body
+	}
+	if status == "resolved" {
+		if link := getResolutionTranscriptLink(record); link != "" {
+			text += "\nTranscript of the incident channel: " + link
+		}
+	}
+	for _, message := range record.AlertMessages {
+		postThreadReply(message, text, status == "resolved")
+	}
+	_, err := incidentStore.Update(record.ID, func(record *IncidentRecord) {
+		if status != "" && status != "current" {
+			record.LastDigestStatus = status
+		}
+	})
+	if err != nil {
+		log.Error("postStakeholderDigest Error: ", err)
+	}
+}
@@ -189,3 +189,20 @@ func UpdateIncident(ctx context.Context, incident *StatusPageIncident, url strin
 	}
 	return &inc, resp, err
 }
+
+//GetIncident fetches an incident using the incident url
+func GetIncident(ctx context.Context, url string) (*StatusPageIncident, *http.Response, error) {
+	index := strings.Index(url, "v1")
+	path := url[index:]
+	path = strings.Trim(path, "<>")
+	req, err := prepareStatusPageRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var inc StatusPageIncident
+	resp, err := callStatusPage(ctx, req, &inc)
+	if err != nil {
+		return nil, resp, err
+	}
+	return &inc, resp, err
+}
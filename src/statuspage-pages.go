@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+const defaultStatusPageName = "default"
+
+const pageArgumentPrefix = "page="
+
+// StatusPageRoute is the set of components an incident affects on one page
+type StatusPageRoute struct {
+	Page         StatusPagePage
+	ComponentIDs []string
+}
+
+// ******************************************************************************
+// Name				: getStatusPage
+// Description: Function to get a configured StatusPage page by name. An empty
+// 							name or "default" refers to statuspage.page_id
+// ******************************************************************************
+func getStatusPage(name string) (StatusPagePage, error) {
+	if name == "" || name == defaultStatusPageName {
+		return StatusPagePage{
+			Name:                 defaultStatusPageName,
+			PageID:               constants.StatusPage.PageID,
+			DeliverNotifications: constants.StatusPage.DeliverNotifications,
+		}, nil
+	}
+	for _, page := range constants.StatusPage.Pages {
+		if page.Name == name {
+			return page, nil
+		}
+	}
+	return StatusPagePage{}, errors.New("StatusPage page \"" + name + "\" is not configured")
+}
+
+// ******************************************************************************
+// Name				: getStatusPageRoutes
+// Description: Function to decide on which pages an incident is created. Pages
+// 							come from statuspage.service_pages for the affected services
+// 							and from the pages of the affected components. Components are
+// 							only attached to the incident on their own page
+// ******************************************************************************
+func getStatusPageRoutes(services []string, components []SPComponent) []StatusPageRoute {
+	var pageNames []string
+	addPage := func(name string) {
+		if name == "" {
+			name = defaultStatusPageName
+		}
+		for _, j := range pageNames {
+			if j == name {
+				return
+			}
+		}
+		pageNames = append(pageNames, name)
+	}
+	for _, service := range services {
+		for _, name := range constants.StatusPage.ServicePages[service] {
+			addPage(name)
+		}
+	}
+	for _, component := range components {
+		addPage(component.Page)
+	}
+	if len(pageNames) == 0 {
+		addPage(defaultStatusPageName)
+	}
+
+	var routes []StatusPageRoute
+	for _, name := range pageNames {
+		page, err := getStatusPage(name)
+		if err != nil {
+			continue
+		}
+		route := StatusPageRoute{Page: page}
+		for _, component := range components {
+			componentPage := component.Page
+			if componentPage == "" {
+				componentPage = defaultStatusPageName
+			}
+			if componentPage == name {
+				route.ComponentIDs = append(route.ComponentIDs, component.ID)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// ******************************************************************************
+// Name				: getStatusPageLink
+// Description: Function to build the API link of a StatusPage incident
+// ******************************************************************************
+func getStatusPageLink(pageID string, incidentID string) string {
+	return "https://api.statuspage.io/v1/pages/" + pageID + "/incidents/" + incidentID
+}
+
+// ******************************************************************************
+// Name				: isPageArgument
+// Description: Function to check if an argument selects a StatusPage page
+// ******************************************************************************
+func isPageArgument(argument string) bool {
+	return strings.HasPrefix(strings.TrimSpace(argument), pageArgumentPrefix)
+}
+
+// ******************************************************************************
+// Name				: getPageArgument
+// Description: Function to get the page selected with `page=<name>` in the
+// 							command arguments, if any
+// ******************************************************************************
+func getPageArgument(arguments []string) string {
+	for _, argument := range arguments[1:] {
+		if isPageArgument(argument) {
+			return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(argument), pageArgumentPrefix))
+		}
+	}
+	return ""
+}
+
+// ******************************************************************************
+// Name				: getStatusPageRefLinks
+// Description: Function to get the links of the StatusPage incidents on a page,
+// 							or on all pages when no page is given
+// ******************************************************************************
+func getStatusPageRefLinks(refs []StatusPageIncidentRef, pageName string) []string {
+	var links []string
+	for _, ref := range refs {
+		if pageName == "" || ref.Page == pageName {
+			links = append(links, getStatusPageLink(ref.PageID, ref.IncidentID))
+		}
+	}
+	return links
+}
+
+// ******************************************************************************
+// Name				: getStatusPageLinkNames
+// Description: Function to get the names of the pages of StatusPage incident
+// 							links, for reporting. Links not tracked in the incident store
+// 							are named by the link itself
+// ******************************************************************************
+func getStatusPageLinkNames(channelID string, links []string) []string {
+	record, _ := incidentStore.FindByChannel(channelID)
+	var names []string
+	for _, link := range links {
+		name := link
+		for _, ref := range record.StatusPageIncidents {
+			if getStatusPageLink(ref.PageID, ref.IncidentID) == link {
+				name = ref.Page
+				break
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// ******************************************************************************
+// Name				: getStatusPageLinks
+// Description: Function to get the StatusPage incidents a comment is posted to.
+// 							Incidents tracked in the incident store are used when
+// 							available, otherwise the link from the channel purpose
+// ******************************************************************************
+func getStatusPageLinks(channelID string, purposeLink string, pageName string) ([]string, error) {
+	record, err := incidentStore.FindByChannel(channelID)
+	if err != nil || len(record.StatusPageIncidents) == 0 {
+		if pageName != "" && pageName != defaultStatusPageName {
+			return nil, errors.New("no StatusPage incident on page \"" + pageName + "\" for this incident")
+		}
+		if purposeLink == "" {
+			return nil, errors.New("no StatusPage incident for this incident")
+		}
+		return []string{purposeLink}, nil
+	}
+	links := getStatusPageRefLinks(record.StatusPageIncidents, pageName)
+	if len(links) == 0 {
+		return nil, errors.New("no StatusPage incident on page \"" + pageName + "\" for this incident")
+	}
+	return links, nil
+}
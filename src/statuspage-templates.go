@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const templateArgumentPrefix = "template="
+
+// StatusPageTemplateData holds the variables available inside a StatusPage
+// message template
+type StatusPageTemplateData struct {
+	Title      string
+	Status     string
+	Severity   string
+	Components []string
+	StartedAt  time.Time
+	Shortlink  string
+}
+
+var statusPageTemplateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ******************************************************************************
+// Name				: isTemplateArgument
+// Description: Function to check if the comment argument refers to a template
+// ******************************************************************************
+func isTemplateArgument(argument string) bool {
+	return strings.HasPrefix(strings.TrimSpace(argument), templateArgumentPrefix)
+}
+
+// ******************************************************************************
+// Name				: getTemplateName
+// Description: Function to extract the template name from `template=<name>`
+// ******************************************************************************
+func getTemplateName(argument string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(argument), templateArgumentPrefix))
+}
+
+// ******************************************************************************
+// Name				: getStatusPageTemplatePath
+// Description: Function to find the template file for a phase and severity. A
+// 							severity specific template (<phase>.<severity>.tmpl) takes
+// 							precedence over the generic one (<phase>.tmpl)
+// ******************************************************************************
+func getStatusPageTemplatePath(name string, phase string, severity string) (string, error) {
+	templateDir := filepath.Join(constants.StatusPage.TemplatesDir, filepath.Base(name))
+	candidates := []string{filepath.Join(templateDir, phase+".tmpl")}
+	if severity != "" {
+		candidates = append([]string{filepath.Join(templateDir, phase+"."+severity+".tmpl")}, candidates...)
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("no template \"" + name + "\" found for status \"" + phase + "\"")
+}
+
+// ******************************************************************************
+// Name				: renderStatusPageTemplate
+// Description: Function to render a StatusPage message template for the
+// 							incident behind the given StatusPage link
+// ******************************************************************************
+func renderStatusPageTemplate(name string, status string, statusPageLink string) (string, error) {
+	incident, err := getStatusPageIncident(statusPageLink)
+	if err != nil {
+		return "", err
+	}
+	phase := status
+	if phase == "current" {
+		phase = incident.Status
+	}
+	path, err := getStatusPageTemplatePath(name, phase, incident.Impact)
+	if err != nil {
+		log.Error("renderStatusPageTemplate Error: ", err)
+		return "", err
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(statusPageTemplateFuncs).ParseFiles(path)
+	if err != nil {
+		log.Error("renderStatusPageTemplate Parse Error: ", err)
+		return "", err
+	}
+
+	data := StatusPageTemplateData{
+		Title:     incident.Name,
+		Status:    phase,
+		Severity:  incident.Impact,
+		Shortlink: incident.Shortlink,
+	}
+	for _, component := range incident.Components {
+		if component.Name != nil {
+			data.Components = append(data.Components, *component.Name)
+		}
+	}
+	if incident.StartedAt != nil {
+		data.StartedAt = incident.StartedAt.Time
+	} else if incident.CreatedAt != nil {
+		data.StartedAt = incident.CreatedAt.Time
+	}
+
+	var message bytes.Buffer
+	err = tmpl.Execute(&message, data)
+	if err != nil {
+		log.Error("renderStatusPageTemplate Execute Error: ", err)
+		return "", err
+	}
+	return strings.TrimSpace(message.String()), nil
+}
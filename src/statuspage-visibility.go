@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	visibilityPublic        = "public"
+	visibilityInternal      = "internal"
+	visibilityDelayedPublic = "delayed-public"
+)
+
+const visibilityArgumentPrefix = "visibility="
+
+// PendingStatusPageUpdate is a StatusPage update of a delayed-public incident
+// waiting for approval from the comms role. Updates without links are posted to
+// the StatusPage incidents created when the update is approved
+type PendingStatusPageUpdate struct {
+	ID          int       `json:"id"`
+	Status      string    `json:"status"`
+	Body        string    `json:"body"`
+	Links       []string  `json:"links"`
+	Page        string    `json:"page,omitempty"`
+	RequestedBy string    `json:"requested_by"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// ******************************************************************************
+// Name				: isValidVisibility
+// Description: Function to validate an incident visibility mode
+// ******************************************************************************
+func isValidVisibility(visibility string) bool {
+	return visibility == visibilityPublic || visibility == visibilityInternal || visibility == visibilityDelayedPublic
+}
+
+// ******************************************************************************
+// Name				: isVisibilityArgument
+// Description: Function to check if an argument sets the incident visibility
+// ******************************************************************************
+func isVisibilityArgument(argument string) bool {
+	return strings.HasPrefix(strings.TrimSpace(argument), visibilityArgumentPrefix)
+}
+
+// ******************************************************************************
+// Name				: splitVisibilityArgument
+// Description: Function to separate a trailing `visibility=<mode>` argument from
+// 							the positional command arguments
+// ******************************************************************************
+func splitVisibilityArgument(arguments []string) ([]string, string) {
+	if len(arguments) > 1 && isVisibilityArgument(arguments[len(arguments)-1]) {
+		visibility := strings.TrimPrefix(strings.TrimSpace(arguments[len(arguments)-1]), visibilityArgumentPrefix)
+		return arguments[:len(arguments)-1], strings.TrimSpace(visibility)
+	}
+	return arguments, ""
+}
+
+// ******************************************************************************
+// Name				: getIncidentVisibility
+// Description: Function to get the visibility mode of an incident. Incidents
+// 							without a mode are public
+// ******************************************************************************
+func getIncidentVisibility(record IncidentRecord) string {
+	if record.Visibility == "" {
+		return visibilityPublic
+	}
+	return record.Visibility
+}
+
+// ******************************************************************************
+// Name				: isCommsMember
+// Description: Function to check if a Slack user has the comms role, which is
+// 							allowed to publish StatusPage updates of delayed-public
+// 							incidents
+// ******************************************************************************
+func isCommsMember(userID string) bool {
+	for _, j := range constants.Slack.CommsUserIDs {
+		if j == userID {
+			return true
+		}
+	}
+	if constants.Slack.CommsUserGroupID != "" {
+		members, err := getUserGroupMembers(constants.Slack.CommsUserGroupID)
+		if err != nil {
+			return false
+		}
+		for _, j := range members {
+			if j == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ******************************************************************************
+// Name				: canPublishStatusPage
+// Description: Function to check if a Slack user may approve StatusPage updates
+// 							or make an incident public. Anyone may when no comms role
+// 							is configured
+// ******************************************************************************
+func canPublishStatusPage(userID string) bool {
+	if constants.Slack.CommsUserGroupID == "" && len(constants.Slack.CommsUserIDs) == 0 {
+		return true
+	}
+	return isCommsMember(userID)
+}
+
+// ******************************************************************************
+// Name				: getCommsMention
+// Description: Function to get the Slack mention of the comms role
+// ******************************************************************************
+func getCommsMention() string {
+	if constants.Slack.CommsUserGroupID != "" {
+		return "<!subteam^" + constants.Slack.CommsUserGroupID + ">"
+	}
+	var mentions []string
+	for _, j := range constants.Slack.CommsUserIDs {
+		mentions = append(mentions, "<@"+j+">")
+	}
+	if len(mentions) == 0 {
+		return "the comms role"
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// ******************************************************************************
+// Name				: queueStatusPageUpdate
+// Description: Function to keep a StatusPage update until it is approved
+// ******************************************************************************
+func queueStatusPageUpdate(incidentID int, status string, body string, links []string, page string, userID string) (PendingStatusPageUpdate, error) {
+	var update PendingStatusPageUpdate
+	_, err := incidentStore.Update(incidentID, func(record *IncidentRecord) {
+		record.LastPendingUpdateID++
+		update = PendingStatusPageUpdate{
+			ID:          record.LastPendingUpdateID,
+			Status:      status,
+			Body:        body,
+			Links:       links,
+			Page:        page,
+			RequestedBy: userID,
+			RequestedAt: time.Now(),
+		}
+		record.PendingUpdates = append(record.PendingUpdates, update)
+	})
+	if err != nil {
+		log.Error("queueStatusPageUpdate Error: ", err)
+	}
+	return update, err
+}
+
+// ******************************************************************************
+// Name				: takePendingUpdate
+// Description: Function to remove a pending StatusPage update from the incident
+// 							and return it
+// ******************************************************************************
+func takePendingUpdate(incidentID int, updateID string) (PendingStatusPageUpdate, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(updateID, "#"))
+	if err != nil {
+		return PendingStatusPageUpdate{}, errors.New("invalid update id \"" + updateID + "\"")
+	}
+	var update PendingStatusPageUpdate
+	found := false
+	_, err = incidentStore.Update(incidentID, func(record *IncidentRecord) {
+		for i, j := range record.PendingUpdates {
+			if j.ID == id {
+				update = j
+				found = true
+				record.PendingUpdates = append(record.PendingUpdates[:i], record.PendingUpdates[i+1:]...)
+				return
+			}
+		}
+	})
+	if err != nil {
+		return update, err
+	}
+	if !found {
+		return update, errors.New("no pending StatusPage update #" + strconv.Itoa(id))
+	}
+	return update, nil
+}
+
+// ******************************************************************************
+// Name				: publishIncidentOnStatusPage
+// Description: Function to create the StatusPage incidents of an incident that
+// 							was not public when it was declared. Incidents that already
+// 							have StatusPage incidents are left as they are
+// ******************************************************************************
+func publishIncidentOnStatusPage(record IncidentRecord) ([]StatusPageIncidentRef, error) {
+	if len(record.StatusPageIncidents) > 0 {
+		return record.StatusPageIncidents, nil
+	}
+	routes := getStatusPageRoutes(record.Services, getServiceSPComponents(record.Services))
+	refs, err := createStatusPageIncidents(record.Title, "", record.Severity, routes)
+	if len(refs) == 0 {
+		if err == nil {
+			err = errors.New("no StatusPage incident was created")
+		}
+		log.Error("publishIncidentOnStatusPage Error: ", err)
+		return nil, err
+	}
+	if err != nil {
+		// The pages that failed are left out, as on declaration
+		log.Error("publishIncidentOnStatusPage Error: ", err)
+	}
+	_, err = updateIncident(record.ID, func(record *IncidentRecord) {
+		record.StatusPageIncidents = append(record.StatusPageIncidents, refs...)
+	})
+	if err != nil {
+		log.Error("publishIncidentOnStatusPage Incident Store Error: ", err)
+	}
+	return refs, nil
+}
+
+// ******************************************************************************
+// Name				: publishPendingUpdate
+// Description: Function to publish an approved StatusPage update. The
+// 							StatusPage incidents are created first if the incident has none
+// 							yet. Returns the links the update could not be posted to
+// ******************************************************************************
+func publishPendingUpdate(record IncidentRecord, update PendingStatusPageUpdate) ([]string, error) {
+	links := update.Links
+	if len(links) == 0 {
+		refs, err := publishIncidentOnStatusPage(record)
+		if err != nil {
+			return nil, err
+		}
+		links = getStatusPageRefLinks(refs, update.Page)
+		if len(links) == 0 {
+			return nil, errors.New("no StatusPage incident on page \"" + update.Page + "\" for this incident")
+		}
+	}
+	var failed []string
+	var err error
+	for _, link := range links {
+		_, linkErr := updateStatusPageIncident([]string{update.Status, update.Body}, link)
+		if linkErr != nil {
+			failed = append(failed, link)
+			err = linkErr
+		}
+	}
+	return failed, err
+}
@@ -1,6 +1,15 @@
 package main
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
 	"github.com/slack-go/slack"
 )
 
@@ -35,3 +44,50 @@ func isArgumentFormatValid(runeText []rune, s slack.SlashCommand) bool {
 	}
 	return true
 }
+
+// ******************************************************************************
+// Name				: isSlackRequestVerified
+// Description: Function to verify the signature of a request sent by Slack.
+// 							Requests are rejected when SLACK_SIGNING_SECRET is unset
+// ******************************************************************************
+func isSlackRequestVerified(header http.Header, body []byte) bool {
+	secret := os.Getenv("SLACK_SIGNING_SECRET")
+	if secret == "" {
+		log.Error("isSlackRequestVerified Error: SLACK_SIGNING_SECRET is not set")
+		return false
+	}
+	verifier, err := slack.NewSecretsVerifier(header, secret)
+	if err != nil {
+		log.Error("isSlackRequestVerified Error: ", err)
+		return false
+	}
+	verifier.Write(body)
+	err = verifier.Ensure()
+	if err != nil {
+		log.Error("isSlackRequestVerified Error: ", err)
+		return false
+	}
+	return true
+}
+
+// ******************************************************************************
+// Name				: isJiraRequestVerified
+// Description: Function to verify that a webhook was sent by JIRA, either by the
+// 							HMAC signature of the body in X-Hub-Signature or by the
+// 							secret query parameter of the webhook URL. Requests are
+// 							rejected when JIRA_WEBHOOK_SECRET is unset
+// ******************************************************************************
+func isJiraRequestVerified(r *http.Request, body []byte) bool {
+	secret := os.Getenv("JIRA_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Error("isJiraRequestVerified Error: JIRA_WEBHOOK_SECRET is not set")
+		return false
+	}
+	if signature := r.Header.Get("X-Hub-Signature"); signature != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected))
+	}
+	return subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("secret")), []byte(secret)) == 1
+}